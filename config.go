@@ -0,0 +1,355 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Default values used when an option is set neither in the config file,
+// nor in the environment, nor on the command line.
+const (
+	defaultUser   = "admin@internal"
+	defaultScope  = "ovirt-app-api"
+	defaultCAFile = "./oVirt_CA/ovirt_ca.pem"
+
+	defaultPageSize         = 500
+	defaultParallelism      = 8
+	defaultTimeout          = time.Minute
+	defaultAttempts         = 4
+	defaultInitialBackoff   = time.Second
+	defaultMaxBackoff       = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = time.Minute
+	defaultOldSnapshotDays  = 30
+)
+
+// Modes of fetching VMs with their disks.
+const (
+	// VMs come with disk attachments, disks, NICs and reported devices in one request, see vmFollowLinks.
+	fetchFollow = "follow"
+	// VMs, disks and disk attachments of every VM are fetched in separate requests.
+	fetchSeparate = "separate"
+)
+
+// Environment variables read by loadConfig.
+const (
+	envConfig = "OVIRT_CONFIG"
+	envEngine = "OVIRT_ENGINE"
+	envUser   = "OVIRT_USER"
+	envPass   = "OVIRT_PASS"
+	envScope  = "OVIRT_SCOPE"
+	envCAFile = "OVIRT_CA_FILE"
+	// InfluxDB API token of the output, see outputConfig.Token.
+	envInfluxToken = "OVIRT_INFLUX_TOKEN"
+)
+
+// Separators of list values given in the environment or on the command line.
+const listDelims = ", "
+
+type config struct {
+	// Top level engine options. They describe the only engine when Engines is empty,
+	// otherwise they are defaults for every engine in the list.
+	engineConfig    `yaml:",inline"`
+	Engines         []engineConfig `yaml:"engines" toml:"engines"`                     // oVirt engines to inventory in one run.
+	FetchMode       string         `yaml:"fetch_mode" toml:"fetch_mode"`               // How VMs are fetched with their disks, fetchFollow or fetchSeparate.
+	PageSize        int            `yaml:"page_size" toml:"page_size"`                 // Count of items in a page of collections, 0 disables paging.
+	Parallelism     int            `yaml:"parallelism" toml:"parallelism"`             // Max count of concurrent per-VM requests, per engine.
+	RateLimit       float64        `yaml:"rate_limit" toml:"rate_limit"`               // Max requests per second, per engine, 0 is unlimited.
+	Timeout         time.Duration  `yaml:"timeout" toml:"timeout"`                     // Timeout of a single attempt of a request to the engine API.
+	Retry           retryConfig    `yaml:"retry" toml:"retry"`                         // Retry and circuit breaking of requests, per engine.
+	StorageTiers    tiersConfig    `yaml:"storage_tiers" toml:"storage_tiers"`         // Rules of classification of storage domains into tiers.
+	OldSnapshotDays int            `yaml:"old_snapshot_days" toml:"old_snapshot_days"` // Age of snapshots reported as old, in days, 0 disables the report.
+	Output          outputConfig   `yaml:"output" toml:"output"`                       // Format and destination of the inventory.
+	Mode            string         `yaml:"mode" toml:"mode"`                           // Mode of the app, modeReport or modeExporter.
+	Exporter        exporterConfig `yaml:"exporter" toml:"exporter"`                   // Options of the exporter mode.
+
+	classifier *tierClassifier // Compiled StorageTiers rules.
+}
+
+// Retry of idempotent requests to the engine and circuit breaking of the engine, see retryTransport and breakerTransport.
+type retryConfig struct {
+	Attempts         int           `yaml:"attempts" toml:"attempts"`                   // Max count of attempts of a request, 1 disables retries.
+	InitialBackoff   time.Duration `yaml:"initial_backoff" toml:"initial_backoff"`     // Max delay before the first retry.
+	MaxBackoff       time.Duration `yaml:"max_backoff" toml:"max_backoff"`             // Max delay before any retry.
+	BreakerThreshold int           `yaml:"breaker_threshold" toml:"breaker_threshold"` // Failures in a row to open the circuit, 0 disables circuit breaker.
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" toml:"breaker_cooldown"`   // Time the circuit stays open.
+}
+
+// Connection options of a single oVirt engine.
+type engineConfig struct {
+	Name        string   `yaml:"name" toml:"name"`                 // Name of the engine in the inventory, FQDN of the engine by default.
+	Engine      string   `yaml:"engine" toml:"engine"`             // FQDN of the oVirt engine, without scheme and path.
+	User        string   `yaml:"user" toml:"user"`                 // oVirt user used for SSO, e.g. admin@internal.
+	Password    string   `yaml:"password" toml:"password"`         // Password of the oVirt user.
+	PasswordEnv string   `yaml:"password_env" toml:"password_env"` // Environment variable to read the password from, instead of Password.
+	Scope       []string `yaml:"scope" toml:"scope"`               // OAuth scopes requested from the engine SSO.
+	CAFile      string   `yaml:"ca_file" toml:"ca_file"`           // PEM file with the engine CA certificate.
+	Search      string   `yaml:"search" toml:"search"`             // oVirt search query of VMs, e.g. "cluster=prod and status=up".
+	DiskSearch  string   `yaml:"disk_search" toml:"disk_search"`   // oVirt search query of disks, used in fetchSeparate mode only.
+}
+
+// func loadConfig - resolve app configuration
+//
+// Options are resolved in the following order, every next source overrides the previous one:
+//  1. built-in defaults
+//  2. config file (-config flag or OVIRT_CONFIG), YAML or TOML by file extension
+//  3. environment variables (OVIRT_ENGINE, OVIRT_USER, OVIRT_PASS, OVIRT_SCOPE, OVIRT_CA_FILE)
+//  4. command line flags
+func loadConfig(args []string) (*config, error) {
+	cfg := &config{
+		engineConfig: engineConfig{
+			User:   defaultUser,
+			Scope:  []string{defaultScope},
+			CAFile: defaultCAFile,
+		},
+		FetchMode:       fetchFollow,
+		PageSize:        defaultPageSize,
+		Parallelism:     defaultParallelism,
+		Timeout:         defaultTimeout,
+		OldSnapshotDays: defaultOldSnapshotDays,
+		Output:          outputConfig{Format: formatJSON},
+		Mode:            modeReport,
+		Exporter:        exporterConfig{Listen: defaultListen, RefreshInterval: defaultRefreshInterval},
+		Retry: retryConfig{
+			Attempts:         defaultAttempts,
+			InitialBackoff:   defaultInitialBackoff,
+			MaxBackoff:       defaultMaxBackoff,
+			BreakerThreshold: defaultBreakerThreshold,
+			BreakerCooldown:  defaultBreakerCooldown,
+		},
+	}
+
+	fs := flag.NewFlagSet("ovirt_inventory", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(envConfig), "path to YAML or TOML config file (env "+envConfig+")")
+	engine := fs.String("engine", "", "FQDN of the oVirt engine (env "+envEngine+")")
+	user := fs.String("user", "", "oVirt user (env "+envUser+")")
+	scope := fs.String("scope", "", "comma separated OAuth scopes (env "+envScope+")")
+	caFile := fs.String("ca-file", "", "PEM file with the engine CA certificate (env "+envCAFile+")")
+	search := fs.String("search", "", "oVirt search query of VMs, e.g. \"cluster=prod and name=web*\"")
+	diskSearch := fs.String("disk-search", "", "oVirt search query of disks, used in "+fetchSeparate+" fetch mode only")
+	fetchMode := fs.String("fetch-mode", "", "how VMs are fetched with their disks: "+fetchFollow+" or "+fetchSeparate)
+	pageSize := fs.Int("page-size", 0, "count of items in a page of collections, 0 disables paging")
+	parallelism := fs.Int("parallelism", 0, "max count of concurrent per-VM requests, per engine")
+	rateLimit := fs.Float64("rate-limit", 0, "max requests per second, per engine, 0 is unlimited")
+	timeout := fs.Duration("timeout", 0, "timeout of a single attempt of a request to the engine API")
+	attempts := fs.Int("attempts", 0, "max count of attempts of a request, 1 disables retries")
+	oldSnapshotDays := fs.Int("old-snapshot-days", 0, "age of snapshots reported as old, in days, 0 disables the report")
+	format := fs.String("format", "", "output format: "+formatJSON+", "+formatNDJSON+", "+formatCSV+", "+formatYAML+", "+formatXLSX+", "+formatHTML+", "+formatInflux+" or "+formatGraphite)
+	output := fs.String("output", "", "path of the output file, \"-\" for stdout")
+	outputURL := fs.String("output-url", "", "InfluxDB write endpoint or tcp://host:port of Graphite to send "+formatInflux+" or "+formatGraphite+" output to")
+	outputCAFile := fs.String("output-ca-file", "", "PEM file with the CA certificate of the https output url")
+	report := fs.String("report", "", "report to write, e.g. vms or hosts, all reports by default, vms in "+formatCSV+" format")
+	columns := fs.String("columns", "", "comma separated columns of the "+formatCSV+" report, all columns by default")
+	mode := fs.String("mode", "", "mode of the app: "+modeReport+" writes the inventory once, "+modeExporter+" serves Prometheus metrics")
+	listen := fs.String("listen", "", "address the exporter listens on, host:port")
+	refreshInterval := fs.Duration("refresh-interval", 0, "interval of inventory collections in exporter mode")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile != "" {
+		if err := readConfigFile(*configFile, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	setFromEnv(&cfg.Engine, envEngine)
+	setFromEnv(&cfg.User, envUser)
+	setFromEnv(&cfg.Password, envPass)
+	setFromEnv(&cfg.CAFile, envCAFile)
+	setFromEnv(&cfg.Output.Token, envInfluxToken)
+	if v := os.Getenv(envScope); v != "" {
+		cfg.Scope = splitList(v)
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "engine":
+			cfg.Engine = *engine
+		case "user":
+			cfg.User = *user
+		case "scope":
+			cfg.Scope = splitList(*scope)
+		case "ca-file":
+			cfg.CAFile = *caFile
+		case "search":
+			cfg.Search = *search
+		case "disk-search":
+			cfg.DiskSearch = *diskSearch
+		case "fetch-mode":
+			cfg.FetchMode = *fetchMode
+		case "page-size":
+			cfg.PageSize = *pageSize
+		case "parallelism":
+			cfg.Parallelism = *parallelism
+		case "rate-limit":
+			cfg.RateLimit = *rateLimit
+		case "timeout":
+			cfg.Timeout = *timeout
+		case "attempts":
+			cfg.Retry.Attempts = *attempts
+		case "old-snapshot-days":
+			cfg.OldSnapshotDays = *oldSnapshotDays
+		case "format":
+			cfg.Output.Format = *format
+		case "output":
+			cfg.Output.File = *output
+		case "output-url":
+			cfg.Output.URL = *outputURL
+		case "output-ca-file":
+			cfg.Output.CAFile = *outputCAFile
+		case "report":
+			cfg.Output.Report = *report
+		case "columns":
+			cfg.Output.Columns = splitList(*columns)
+		case "mode":
+			cfg.Mode = *mode
+		case "listen":
+			cfg.Exporter.Listen = *listen
+		case "refresh-interval":
+			cfg.Exporter.RefreshInterval = *refreshInterval
+		}
+	})
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	classifier, err := newTierClassifier(cfg.StorageTiers)
+	if err != nil {
+		return nil, err
+	}
+	cfg.classifier = classifier
+	return cfg, nil
+}
+
+// func readConfigFile - decode config file into cfg, the format is chosen by file extension
+func readConfigFile(path string, cfg *config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q, use .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *config) validate() error {
+	if len(c.Engines) > 0 && c.Engine != "" {
+		return errors.New("engine and engines are mutually exclusive, list every engine in engines")
+	}
+	if c.FetchMode != fetchFollow && c.FetchMode != fetchSeparate {
+		return fmt.Errorf("unknown fetch mode %q, use %s or %s", c.FetchMode, fetchFollow, fetchSeparate)
+	}
+	if c.PageSize < 0 {
+		return errors.New("page size must not be negative")
+	}
+	if c.Parallelism < 1 {
+		return errors.New("parallelism must be at least 1")
+	}
+	if c.Retry.Attempts < 1 {
+		return errors.New("retry attempts must be at least 1")
+	}
+	if c.OldSnapshotDays < 0 {
+		return errors.New("old snapshot days must not be negative")
+	}
+	switch c.Mode {
+	case modeReport:
+		if err := c.Output.validate(); err != nil {
+			return err
+		}
+	case modeExporter:
+		if err := c.Exporter.validate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown mode %q, use %s or %s", c.Mode, modeReport, modeExporter)
+	}
+	names := make(map[string]bool)
+	for _, e := range c.engines() {
+		if e.Engine == "" {
+			return errors.New("oVirt engine is not set, use -engine flag, " + envEngine + " or engine in config file")
+		}
+		if e.User == "" {
+			return fmt.Errorf("engine %s: oVirt user is not set", e.Name)
+		}
+		if e.PasswordEnv != "" && e.Password == "" {
+			return fmt.Errorf("engine %s: password environment variable %s is not set", e.Name, e.PasswordEnv)
+		}
+		if names[e.Name] {
+			return fmt.Errorf("engine %s: duplicate engine name", e.Name)
+		}
+		names[e.Name] = true
+	}
+	return nil
+}
+
+// func engines - list of engines to inventory with unset options filled from the top level ones
+func (c *config) engines() []engineConfig {
+	if len(c.Engines) == 0 {
+		return []engineConfig{c.withDefaults(c.engineConfig)}
+	}
+	engines := make([]engineConfig, 0, len(c.Engines))
+	for _, e := range c.Engines {
+		engines = append(engines, c.withDefaults(e))
+	}
+	return engines
+}
+
+func (c *config) withDefaults(e engineConfig) engineConfig {
+	if e.User == "" {
+		e.User = c.User
+	}
+	if e.PasswordEnv != "" {
+		// The password of the engine only, the top level one belongs to another engine SSO.
+		e.Password = os.Getenv(e.PasswordEnv)
+	} else if e.Password == "" {
+		e.Password = c.Password
+	}
+	if len(e.Scope) == 0 {
+		e.Scope = c.Scope
+	}
+	if e.CAFile == "" {
+		e.CAFile = c.CAFile
+	}
+	if e.Search == "" {
+		e.Search = c.Search
+	}
+	if e.DiskSearch == "" {
+		e.DiskSearch = c.DiskSearch
+	}
+	if e.Name == "" {
+		e.Name = e.Engine
+	}
+	return e
+}
+
+// func setFromEnv - overwrite dst with the value of environment variable, if it is set
+func setFromEnv(dst *string, name string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = v
+	}
+}
+
+// func splitList - split comma or space separated list, empty items are dropped
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(listDelims, r)
+	})
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		env      map[string]string
+		args     []string
+		wantUser string
+		wantCA   string
+	}{
+		{
+			name:     "defaults",
+			args:     []string{"-engine", "ovirt.example.com"},
+			wantUser: defaultUser,
+			wantCA:   defaultCAFile,
+		},
+		{
+			name:     "file over defaults",
+			file:     "engine: ovirt.example.com\nuser: file@internal\nca_file: file.pem\n",
+			wantUser: "file@internal",
+			wantCA:   "file.pem",
+		},
+		{
+			name:     "env over file",
+			file:     "engine: ovirt.example.com\nuser: file@internal\nca_file: file.pem\n",
+			env:      map[string]string{envUser: "env@internal"},
+			wantUser: "env@internal",
+			wantCA:   "file.pem",
+		},
+		{
+			name:     "flag over env",
+			file:     "engine: ovirt.example.com\nuser: file@internal\n",
+			env:      map[string]string{envUser: "env@internal", envCAFile: "env.pem"},
+			args:     []string{"-user", "flag@internal"},
+			wantUser: "flag@internal",
+			wantCA:   "env.pem",
+		},
+		{
+			name:     "unset flag keeps env",
+			env:      map[string]string{envEngine: "ovirt.example.com", envUser: "env@internal"},
+			args:     []string{"-ca-file", "flag.pem"},
+			wantUser: "env@internal",
+			wantCA:   "flag.pem",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{envConfig, envEngine, envUser, envPass, envScope, envCAFile, envInfluxToken} {
+				t.Setenv(name, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"-config", path}, args...)
+			}
+			cfg, err := loadConfig(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.User != tt.wantUser {
+				t.Errorf("User = %q, want %q", cfg.User, tt.wantUser)
+			}
+			if cfg.CAFile != tt.wantCA {
+				t.Errorf("CAFile = %q, want %q", cfg.CAFile, tt.wantCA)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	for _, name := range []string{envConfig, envEngine, envUser, envPass, envScope, envCAFile, envInfluxToken} {
+		t.Setenv(name, "")
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("engine = \"ovirt.example.com\"\npage_size = 100\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envConfig, path)
+	cfg, err := loadConfig([]string{"-page-size", "50"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Engine != "ovirt.example.com" || cfg.PageSize != 50 {
+		t.Errorf("Engine = %q, PageSize = %d, want ovirt.example.com and 50 from the flag", cfg.Engine, cfg.PageSize)
+	}
+}
+
+func TestConfigEngines(t *testing.T) {
+	t.Setenv("OVIRT_PROD_PASS", "prod-secret")
+	t.Setenv("OVIRT_DR_PASS", "")
+	cfg := &config{
+		engineConfig: engineConfig{User: "reader@internal", Password: "top-secret", Scope: []string{defaultScope},
+			CAFile: "ca.pem", Search: "cluster=prod"},
+		Engines: []engineConfig{
+			{Name: "prod", Engine: "ovirt-prod.example.com", PasswordEnv: "OVIRT_PROD_PASS"},
+			{Engine: "ovirt-test.example.com", User: "admin@internal", CAFile: "test.pem", Search: "name=web*"},
+			{Name: "dr", Engine: "ovirt-dr.example.com", PasswordEnv: "OVIRT_DR_PASS"},
+		},
+	}
+	want := []engineConfig{
+		{Name: "prod", Engine: "ovirt-prod.example.com", User: "reader@internal", Password: "prod-secret",
+			PasswordEnv: "OVIRT_PROD_PASS", Scope: []string{defaultScope}, CAFile: "ca.pem", Search: "cluster=prod"},
+		{Name: "ovirt-test.example.com", Engine: "ovirt-test.example.com", User: "admin@internal", Password: "top-secret",
+			Scope: []string{defaultScope}, CAFile: "test.pem", Search: "name=web*"},
+		// The top level password is not sent to an engine with its own password variable.
+		{Name: "dr", Engine: "ovirt-dr.example.com", User: "reader@internal", PasswordEnv: "OVIRT_DR_PASS",
+			Scope: []string{defaultScope}, CAFile: "ca.pem", Search: "cluster=prod"},
+	}
+	if got := cfg.engines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("engines() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	single := &config{engineConfig: engineConfig{Engine: "ovirt.example.com", User: "reader@internal"}}
+	if got := single.engines(); len(got) != 1 || got[0].Name != "ovirt.example.com" {
+		t.Errorf("engines() of the top level engine = %+v, want the engine named by its FQDN", got)
+	}
+}
+
+func TestConfigValidateEngines(t *testing.T) {
+	t.Setenv("OVIRT_DR_PASS", "")
+	tests := []struct {
+		name    string
+		engines []engineConfig
+		engine  string
+		wantErr string
+	}{
+		{
+			name:    "duplicate names",
+			engines: []engineConfig{{Name: "prod", Engine: "ovirt1.example.com"}, {Name: "prod", Engine: "ovirt2.example.com"}},
+			wantErr: "engine prod: duplicate engine name",
+		},
+		{
+			name:    "duplicate default names",
+			engines: []engineConfig{{Engine: "ovirt.example.com"}, {Engine: "ovirt.example.com"}},
+			wantErr: "engine ovirt.example.com: duplicate engine name",
+		},
+		{
+			name:    "unset password variable",
+			engines: []engineConfig{{Name: "dr", Engine: "ovirt-dr.example.com", PasswordEnv: "OVIRT_DR_PASS"}},
+			wantErr: "engine dr: password environment variable OVIRT_DR_PASS is not set",
+		},
+		{
+			name:    "engine and engines",
+			engine:  "ovirt.example.com",
+			engines: []engineConfig{{Engine: "ovirt1.example.com"}},
+			wantErr: "engine and engines are mutually exclusive, list every engine in engines",
+		},
+		{
+			name:    "engine without address",
+			engines: []engineConfig{{Name: "prod"}},
+			wantErr: "oVirt engine is not set, use -engine flag, " + envEngine + " or engine in config file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config{
+				engineConfig: engineConfig{Engine: tt.engine, User: "reader@internal", Password: "top-secret"},
+				Engines:      tt.engines,
+				FetchMode:    fetchFollow,
+				Parallelism:  1,
+				Retry:        retryConfig{Attempts: 1},
+				Output:       outputConfig{Format: formatJSON},
+				Mode:         modeReport,
+			}
+			err := cfg.validate()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
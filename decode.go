@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Collection of the oVirt REST API, the envelope of a listed resource.
+type collection[T any] interface {
+	items() []T
+}
+
+// func getCollection - GET the collection C from url and return its items
+func getCollection[C collection[T], T any](ctx context.Context, client *http.Client, url string) ([]T, error) {
+	body, err := getRequest(ctx, client, url)
+	if err != nil {
+		return nil, err
+	}
+	var c C
+	if err := unmarshalOvirt(body, &c); err != nil {
+		return nil, err
+	}
+	return c.items(), nil
+}
+
+// func getPages - GET the collection C page by page and pass items of every page to fn
+//
+// A page is requested with "page N" clause appended to the search query and max=pageSize parameter,
+// the last page is the one shorter than pageSize. pageSize <= 0 requests the whole collection at once.
+// Pages are sorted by name unless the search query has its own sortby clause, the engine doesn't
+// guarantee the same order of unsorted results from page to page.
+func getPages[C collection[T], T any](ctx context.Context, client *http.Client, url string, query neturl.Values, search string, pageSize int, fn func([]T) error) error {
+	for page := 1; ; page++ {
+		q := neturl.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		pageSearch := search
+		if pageSize > 0 {
+			q.Set("max", strconv.Itoa(pageSize))
+			if !strings.Contains(strings.ToLower(search), "sortby") {
+				pageSearch += " sortby name"
+			}
+			pageSearch = strings.TrimSpace(pageSearch + " page " + strconv.Itoa(page))
+		}
+		if pageSearch != "" {
+			q.Set("search", pageSearch)
+		}
+		pageURL := url
+		if len(q) > 0 {
+			pageURL += "?" + q.Encode()
+		}
+
+		items, err := getCollection[C](ctx, client, pageURL)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			if err := fn(items); err != nil {
+				return err
+			}
+		}
+		if pageSize <= 0 || len(items) < pageSize {
+			return nil
+		}
+	}
+}
+
+// func unmarshalOvirt - decode JSON representation of oVirt API into v
+//
+// The engine differs from plain JSON in two ways, both are handled here:
+//   - numbers and booleans are sent as strings, e.g. "memory": "1073741824", "stateless": "false"
+//   - lists nested into objects are wrapped into envelopes the same way as collections,
+//     e.g. "disk_attachments": {"disk_attachment": [...]}; an empty list is sent as {}
+//
+// The raw document is converted to the shape of v's type and then decoded by encoding/json.
+func unmarshalOvirt(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	normalized, err := json.Marshal(coerce(raw, reflect.TypeOf(v).Elem()))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, v)
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// func coerce - convert raw JSON value to the shape expected by type t.
+// Values which can't be converted are returned as is, encoding/json reports them.
+func coerce(raw any, t reflect.Type) any {
+	if raw == nil {
+		return nil
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshaler) {
+		return raw
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return coerce(raw, t.Elem())
+	case reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return raw
+		}
+		for key, value := range obj {
+			if f, ok := fieldByJSONName(t, key); ok {
+				obj[key] = coerce(value, f.Type)
+			}
+		}
+		return obj
+	case reflect.Slice:
+		if obj, ok := raw.(map[string]any); ok {
+			raw = unwrapList(obj)
+		}
+		list, ok := raw.([]any)
+		if !ok {
+			return raw
+		}
+		for i := range list {
+			list[i] = coerce(list[i], t.Elem())
+		}
+		return list
+	case reflect.Map:
+		if obj, ok := raw.(map[string]any); ok {
+			for key, value := range obj {
+				obj[key] = coerce(value, t.Elem())
+			}
+		}
+		return raw
+	case reflect.Bool:
+		if s, ok := raw.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+		return raw
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := raw.(string); ok {
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+		return raw
+	case reflect.String:
+		switch v := raw.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+		return raw
+	}
+	return raw
+}
+
+// func unwrapList - get the list out of the envelope {"item": [...]}, an empty envelope {} is an empty list
+func unwrapList(obj map[string]any) any {
+	switch len(obj) {
+	case 0:
+		return []any{}
+	case 1:
+		for _, v := range obj {
+			if list, ok := v.([]any); ok {
+				return list
+			}
+		}
+	}
+	return obj
+}
+
+// func fieldByJSONName - find struct field decoded from the key, matching the rules of encoding/json
+//
+// Fields of the struct itself come first, an exact name before a case-insensitive one. Fields of embedded
+// structs without a JSON name are promoted and searched next, the index of the found field is the full path
+// for reflect.Value.FieldByIndex. Conflicts of promoted names at the same depth are not detected, the first
+// embedded struct wins.
+func fieldByJSONName(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded reflect.StructField
+	var found bool
+	var embedded []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		tag, hasTag := f.Tag.Lookup("json")
+		if tag == "-" {
+			continue
+		}
+		if n, _, _ := strings.Cut(tag, ","); n != "" {
+			name = n
+		}
+		if f.Anonymous && (!hasTag || strings.HasPrefix(tag, ",")) {
+			if ft := indirect(f.Type); ft.Kind() == reflect.Struct {
+				// Exported fields of an embedded struct are promoted, even if the struct type is unexported.
+				embedded = append(embedded, f)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == key {
+			return f, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			folded, found = f, true
+		}
+	}
+	if found {
+		return folded, true
+	}
+	for _, e := range embedded {
+		if f, ok := fieldByJSONName(indirect(e.Type), key); ok {
+			f.Index = append([]int{e.Index[0]}, f.Index...)
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// func indirect - the type a pointer type points to, other types as is
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
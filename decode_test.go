@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestUnmarshalOvirt(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Vm
+	}{
+		{
+			name: "string encoded numbers and booleans",
+			data: `{"vm":[{"id":"vm1","memory":"1073741824","stateless":"true","delete_protected":"false"}]}`,
+			want: []Vm{{ID: "vm1", Memory: 1 << 30, Stateless: true}},
+		},
+		{
+			name: "plain JSON numbers and booleans",
+			data: `{"vm":[{"id":"vm1","memory":1073741824,"stateless":true}]}`,
+			want: []Vm{{ID: "vm1", Memory: 1 << 30, Stateless: true}},
+		},
+		{
+			name: "number into string field",
+			data: `{"vm":[{"id":"vm1","name":42}]}`,
+			want: []Vm{{ID: "vm1", Name: "42"}},
+		},
+		{
+			name: "empty collection",
+			data: `{}`,
+			want: nil,
+		},
+		{
+			name: "nested lists",
+			data: `{"vm":[{"id":"vm1","disk_attachments":{"disk_attachment":[{"id":"da1","bootable":"true",
+				"disk":{"id":"d1","provisioned_size":"10737418240","storage_domains":{"storage_domain":[{"id":"sd1"}]}}}]}}]}`,
+			want: []Vm{{ID: "vm1", DiskAttachments: []DiskAttachment{{ID: "da1", Bootable: true,
+				Disk: Disk{ID: "d1", ProvisionedSize: 10 << 30, StorageDomains: []StorageDomain{{ID: "sd1"}}}}}}},
+		},
+		{
+			name: "empty nested list",
+			data: `{"vm":[{"id":"vm1","disk_attachments":{}}]}`,
+			want: []Vm{{ID: "vm1", DiskAttachments: []DiskAttachment{}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Vms
+			if err := unmarshalOvirt([]byte(tt.data), &c); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.items(), tt.want) {
+				t.Errorf("unmarshalOvirt() = %+v, want %+v", c.items(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalOvirtFloat(t *testing.T) {
+	var c Hosts
+	if err := unmarshalOvirt([]byte(`{"host":[{"cpu":{"speed":"2600.5","level":"3"}}]}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.items()[0].CPU; got.Speed != 2600.5 || got.Level != 3 {
+		t.Errorf("unmarshalOvirt() CPU = %+v, want speed 2600.5 and level 3", got)
+	}
+}
+
+func TestUnmarshalOvirtErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"malformed number", `{"vm":[{"memory":"1G"}]}`},
+		{"malformed boolean", `{"vm":[{"stateless":"yes"}]}`},
+		{"object for number", `{"vm":[{"memory":{}}]}`},
+		{"malformed JSON", `{"vm":[`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Vms
+			if err := unmarshalOvirt([]byte(tt.data), &c); err == nil {
+				t.Errorf("unmarshalOvirt(%s) succeeded, want error", tt.data)
+			}
+		})
+	}
+}
+
+type decodeEmbedded struct {
+	Size int `json:"size"`
+}
+
+type decodeOuter struct {
+	Name string `json:"name"`
+	decodeEmbedded
+	Tagged decodeEmbedded `json:"tagged"`
+}
+
+func TestFieldByJSONName(t *testing.T) {
+	typ := reflect.TypeOf(decodeOuter{})
+	tests := []struct {
+		key       string
+		wantIndex []int
+		wantOK    bool
+	}{
+		{"name", []int{0}, true},
+		{"NAME", []int{0}, true},
+		{"size", []int{1, 0}, true},
+		{"tagged", []int{2}, true},
+		{"decodeEmbedded", nil, false},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		f, ok := fieldByJSONName(typ, tt.key)
+		if ok != tt.wantOK || (ok && !reflect.DeepEqual(f.Index, tt.wantIndex)) {
+			t.Errorf("fieldByJSONName(%q) = %v, %v, want %v, %v", tt.key, f.Index, ok, tt.wantIndex, tt.wantOK)
+		}
+	}
+
+	var v decodeOuter
+	if err := unmarshalOvirt([]byte(`{"name":"a","size":"3","tagged":{"size":"4"}}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (decodeOuter{Name: "a", decodeEmbedded: decodeEmbedded{Size: 3}, Tagged: decodeEmbedded{Size: 4}}); v != want {
+		t.Errorf("unmarshalOvirt() = %+v, want %+v", v, want)
+	}
+}
+
+func TestGetPages(t *testing.T) {
+	tests := []struct {
+		name       string
+		vms        int
+		search     string
+		pageSize   int
+		wantSearch []string
+		wantPages  []int
+	}{
+		{
+			name:       "short last page",
+			vms:        5,
+			pageSize:   2,
+			wantSearch: []string{"sortby name page 1", "sortby name page 2", "sortby name page 3"},
+			wantPages:  []int{2, 2, 1},
+		},
+		{
+			name:       "empty last page",
+			vms:        4,
+			pageSize:   2,
+			wantSearch: []string{"sortby name page 1", "sortby name page 2", "sortby name page 3"},
+			wantPages:  []int{2, 2},
+		},
+		{
+			name:       "search",
+			vms:        1,
+			search:     "cluster=prod and status=up",
+			pageSize:   2,
+			wantSearch: []string{"cluster=prod and status=up sortby name page 1"},
+			wantPages:  []int{1},
+		},
+		{
+			name:       "own sortby",
+			vms:        3,
+			search:     "cluster=prod sortby memory desc",
+			pageSize:   3,
+			wantSearch: []string{"cluster=prod sortby memory desc page 1", "cluster=prod sortby memory desc page 2"},
+			wantPages:  []int{3},
+		},
+		{
+			name:       "paging disabled",
+			vms:        5,
+			search:     "cluster=prod",
+			wantSearch: []string{"cluster=prod"},
+			wantPages:  []int{5},
+		},
+	}
+	pageRe := regexp.MustCompile(`page (\d+)$`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSearch []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				q := r.URL.Query()
+				gotSearch = append(gotSearch, q.Get("search"))
+				if got := q.Get("follow"); got != "nics" {
+					t.Errorf("follow = %q, want %q", got, "nics")
+				}
+				first, last := 0, tt.vms
+				if tt.pageSize > 0 {
+					if got, want := q.Get("max"), strconv.Itoa(tt.pageSize); got != want {
+						t.Errorf("max = %q, want %q", got, want)
+					}
+					m := pageRe.FindStringSubmatch(q.Get("search"))
+					if m == nil {
+						t.Errorf("search %q has no page clause", q.Get("search"))
+						return
+					}
+					page, _ := strconv.Atoi(m[1])
+					if first = (page - 1) * tt.pageSize; first > tt.vms {
+						first = tt.vms
+					}
+					if last = first + tt.pageSize; last > tt.vms {
+						last = tt.vms
+					}
+				} else if q.Has("max") {
+					t.Errorf("max = %q, want unset", q.Get("max"))
+				}
+				var vms Vms
+				for i := first; i < last; i++ {
+					vms.Vm = append(vms.Vm, Vm{ID: strconv.Itoa(i)})
+				}
+				json.NewEncoder(w).Encode(vms)
+			}))
+			defer server.Close()
+
+			var gotPages []int
+			var ids []string
+			err := getPages[Vms](context.Background(), server.Client(), server.URL+"/vms", neturl.Values{"follow": {"nics"}}, tt.search, tt.pageSize, func(page []Vm) error {
+				gotPages = append(gotPages, len(page))
+				for _, vm := range page {
+					ids = append(ids, vm.ID)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("getPages() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotSearch, tt.wantSearch) {
+				t.Errorf("search = %q, want %q", gotSearch, tt.wantSearch)
+			}
+			if !reflect.DeepEqual(gotPages, tt.wantPages) {
+				t.Errorf("pages = %v, want %v", gotPages, tt.wantPages)
+			}
+			if len(ids) != tt.vms {
+				t.Errorf("got %d VMs, want %d", len(ids), tt.vms)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+// Disks of an engine with the VMs they are attached to, collected page by page of VMs.
+type diskCollector struct {
+	rows    []diskStats
+	index   map[string]int    // Disk ID to index of its row.
+	domains map[string]string // Storage domain ID to name.
+	tiers   storageTiers
+}
+
+func newDiskCollector(domains []StorageDomain, tiers storageTiers) *diskCollector {
+	c := &diskCollector{
+		index:   make(map[string]int),
+		domains: make(map[string]string, len(domains)),
+		tiers:   tiers,
+	}
+	for _, d := range domains {
+		c.domains[d.ID] = d.Name
+	}
+	return c
+}
+
+// func add - add disks of the page not seen yet and link them to VMs of the page.
+// In fetchSeparate mode every page comes with the list of all disks, each disk is listed once.
+func (c *diskCollector) add(vms []Vm, pageDisks []Disk, disksForVms []vmDisks) {
+	for _, disk := range pageDisks {
+		if _, ok := c.index[disk.ID]; ok {
+			continue
+		}
+		c.index[disk.ID] = len(c.rows)
+		c.rows = append(c.rows, c.diskStats(disk))
+	}
+	vmsDisksMap := vmDisksMap(disksForVms)
+	for _, vm := range vms {
+		for _, attachment := range vmsDisksMap[vm.ID] {
+			if i, ok := c.index[attachment.Disk.ID]; ok {
+				c.rows[i].VMs = append(c.rows[i].VMs, vm.Name)
+			}
+		}
+	}
+}
+
+func (c *diskCollector) diskStats(disk Disk) diskStats {
+	stats := diskStats{
+		ID:              disk.ID,
+		Alias:           diskName(disk),
+		Description:     disk.Description,
+		Tier:            c.tiers.diskTier(disk),
+		Format:          disk.Format,
+		Sparse:          disk.Sparse,
+		Shareable:       disk.Shareable,
+		Status:          disk.Status,
+		ContentType:     disk.ContentType,
+		ProvisionedSize: disk.ProvisionedSize,
+		ActualSize:      disk.ActualSize,
+		TotalSize:       disk.TotalSize,
+	}
+	for _, domain := range disk.StorageDomains {
+		stats.StorageDomains = append(stats.StorageDomains, c.domains[domain.ID])
+	}
+	return stats
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiskCollector(t *testing.T) {
+	tiers := testStorageTiers(t)
+	domains := []StorageDomain{{ID: "sd-ssd", Name: "FC-SSD-01"}, {ID: "sd-hdd", Name: "NFS-01"}}
+	disks := []Disk{
+		{ID: "d1", Alias: "web01_Disk1", ProvisionedSize: 10 << 30, ActualSize: 2 << 30, StorageDomains: []StorageDomain{{ID: "sd-ssd"}}},
+		{ID: "d2", Shareable: true, StorageDomains: []StorageDomain{{ID: "sd-hdd"}}},
+	}
+	c := newDiskCollector(domains, tiers)
+	// In fetchSeparate mode every page comes with all disks.
+	c.add([]Vm{{ID: "vm1", Name: "web01"}}, disks, []vmDisks{
+		{vmID: "vm1", diskAttachments: []DiskAttachment{{Disk: Disk{ID: "d1"}}, {Disk: Disk{ID: "d2"}}}},
+	})
+	c.add([]Vm{{ID: "vm2", Name: "db01"}}, disks, []vmDisks{
+		{vmID: "vm2", diskAttachments: []DiskAttachment{{Disk: Disk{ID: "d2"}}}},
+	})
+
+	want := []diskStats{
+		{ID: "d1", Alias: "web01_Disk1", VMs: []string{"web01"}, StorageDomains: []string{"FC-SSD-01"}, Tier: "ssd",
+			ProvisionedSize: 10 << 30, ActualSize: 2 << 30},
+		{ID: "d2", Alias: "d2", VMs: []string{"web01", "db01"}, StorageDomains: []string{"NFS-01"}, Tier: "hdd", Shareable: true},
+	}
+	if !reflect.DeepEqual(c.rows, want) {
+		t.Errorf("diskCollector.rows = %+v, want %+v", c.rows, want)
+	}
+}
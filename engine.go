@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Connection to a single oVirt engine.
+type engine struct {
+	name            string          // Name of the engine in the inventory.
+	apiURL          string          // Base URL of the engine REST API.
+	client          *http.Client    // Client authorized with the engine SSO token.
+	parallelism     int             // Max count of concurrent per-VM requests.
+	fetchMode       string          // How VMs are fetched with their disks, fetchFollow or fetchSeparate.
+	search          string          // oVirt search query of VMs, empty for all VMs.
+	diskSearch      string          // oVirt search query of disks in fetchSeparate mode, empty for all disks.
+	pageSize        int             // Count of items in a page of collections, 0 to fetch collections in one request.
+	classifier      *tierClassifier // Classifier of storage domains into tiers.
+	oldSnapshotDays int             // Age of snapshots reported as old, in days, 0 disables the report.
+}
+
+// func newEngine - authenticate on the engine SSO and prepare the API client
+//
+// The client repeats the login once the token expires, so the engine may be reused by later inventories
+// as long as ctx is not done.
+func newEngine(ctx context.Context, ec engineConfig, cfg *config) (*engine, error) {
+	conf := &oauth2.Config{
+		ClientID:     ec.User,
+		ClientSecret: ec.Password,
+		Scopes:       ec.Scope,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   "https://" + ec.Engine + apiUrl,
+			TokenURL:  "https://" + ec.Engine + tokenURL,
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+
+	// Use the custom HTTP client when requesting a token.
+	transport, err := tlsTranstort(ec.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	// Every attempt of a request is counted by the circuit breaker and the rate limiter of the engine
+	// and limited by the timeout on its own.
+	tlsClient := &http.Client{
+		Transport: newRetryTransport(newBreakerTransport(newRateLimitTransport(transport, cfg.RateLimit), cfg.Retry), cfg.Retry, cfg.Timeout),
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, tlsClient)
+	source := &passwordTokenSource{ctx: ctx, conf: conf, name: ec.Name}
+	token, err := source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	client := oauth2.NewClient(ctx, oauth2.ReuseTokenSource(token, source))
+	return &engine{
+		name:            ec.Name,
+		apiURL:          "https://" + ec.Engine + apiUrl,
+		client:          client,
+		parallelism:     cfg.Parallelism,
+		fetchMode:       cfg.FetchMode,
+		search:          ec.Search,
+		diskSearch:      ec.DiskSearch,
+		pageSize:        cfg.PageSize,
+		classifier:      cfg.classifier,
+		oldSnapshotDays: cfg.OldSnapshotDays,
+	}, nil
+}
+
+// Token source of the engine SSO, every token is requested with the password grant.
+// The engine SSO issues no refresh tokens, an expired token is replaced with a new login.
+type passwordTokenSource struct {
+	ctx  context.Context
+	conf *oauth2.Config
+	name string // Name of the engine.
+}
+
+func (s *passwordTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.conf.PasswordCredentialsToken(s.ctx, s.conf.ClientID, s.conf.ClientSecret)
+	if err != nil {
+		var re *oauth2.RetrieveError
+		if errors.As(err, &re) {
+			// The engine SSO refused the credentials.
+			return nil, fmt.Errorf("%w: %v", errAuthentication, err)
+		}
+		return nil, err
+	}
+	log.Printf("engine %s: token type %v, expiry %v", s.name, token.TokenType, token.Expiry)
+	return token, nil
+}
+
+// func inventory - fetch VMs with their disks, NICs and snapshots, storage domains, hosts, clusters,
+// data centers, templates and networks from the engine and compose stats. Every row is tagged with the engine name.
+//
+// VMs are fetched page by page, stats of a page are composed before the next page is fetched,
+// so only one page of raw VMs, with their followed links, is kept in memory. The composed rows of VMs,
+// their disks and snapshots are kept for the whole inventory, the memory grows with the count of VMs.
+// Rows of streamedReports of every page are passed to sink as soon as the page is composed, unless sink is nil.
+func (e *engine) inventory(ctx context.Context, sink rowSink) (*inventory, error) {
+	now := time.Now()
+	dataCenters, err := dataCentersList(ctx, e.client, e.apiURL)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := clustersList(ctx, e.client, e.apiURL)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := e.hosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	place := newPlacement(clusters, dataCenters, hosts)
+
+	templateFollow := templateFollowLinks
+	if e.fetchMode == fetchSeparate {
+		templateFollow = ""
+	}
+	templates, err := templatesList(ctx, e.client, e.apiURL, templateFollow, e.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	templateLinks := newTemplateIndex(templates)
+
+	profiles, err := vnicProfilesList(ctx, e.client, e.apiURL)
+	if err != nil {
+		return nil, err
+	}
+	networks, err := networksList(ctx, e.client, e.apiURL)
+	if err != nil {
+		return nil, err
+	}
+	qoss, err := qosList(ctx, e.client, e.apiURL, dataCenters)
+	if err != nil {
+		return nil, err
+	}
+	networkLinks := newNetworkIndex(networks, profiles, qoss)
+
+	domains, err := storageDomainsList(ctx, e.client, e.apiURL, e.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	tiers := newStorageTiers(e.classifier, domains)
+
+	follow := vmFollowLinks
+	var diskList []Disk
+	if e.fetchMode == fetchSeparate {
+		follow = ""
+		diskList, err = vmsDisks(ctx, e.client, e.apiURL, e.diskSearch, e.pageSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inv := &inventory{}
+	counted := make(sharedDisks)
+	usage := newDomainUsage()
+	disks := newDiskCollector(domains, tiers)
+	nicUsage := newNetworkUsage(e.search != "")
+	err = vmsList(ctx, e.client, e.apiURL, follow, e.search, e.pageSize, func(vms []Vm) error {
+		pageDisks, disksForVms, err := e.vmsDisks(ctx, vms, diskList)
+		if err != nil {
+			return err
+		}
+		pageStats := composeStats(vms, pageDisks, disksForVms, counted, tiers, now)
+		place.placeVms(pageStats, vms)
+		templateLinks.linkVms(pageStats, vms)
+		nicsForVms, err := e.nics(ctx, vms)
+		if err != nil {
+			return err
+		}
+		composeNics(pageStats, vms, nicsForVms, networkLinks)
+		nicUsage.add(vms, nicsForVms, networkLinks)
+		snapshotsForVms, err := e.snapshots(ctx, vms)
+		if err != nil {
+			return err
+		}
+		pageSnapshots := composeSnapshotStats(pageStats, vms, snapshotsForVms, e.oldSnapshotDays, now)
+		inv.Snapshots = append(inv.Snapshots, pageSnapshots...)
+		inv.VMs = append(inv.VMs, pageStats...)
+		usage.add(vms, pageDisks, disksForVms)
+		disks.add(vms, pageDisks, disksForVms)
+		if sink == nil {
+			return nil
+		}
+		// The rows share arrays with the page, they are tagged with the engine name in place.
+		(&inventory{VMs: pageStats, Snapshots: pageSnapshots}).setEngine(e.name)
+		if err := sink("vms", pageStats); err != nil {
+			return err
+		}
+		return sink("snapshots", pageSnapshots)
+	})
+	if err != nil {
+		return nil, err
+	}
+	inv.Disks = disks.rows
+	inv.StorageDomains = composeStorageDomainStats(domains, tiers, usage)
+	inv.Hosts = composeHostStats(hosts)
+	place.placeHosts(inv.Hosts, hosts)
+	inv.Clusters = composeClusterStats(clusters, place, inv.VMs, inv.Hosts)
+	inv.DataCenters = composeDataCenterStats(dataCenters, inv.Clusters)
+	templateDisks, disksForTemplates := e.templatesDisks(ctx, templates, diskList)
+	inv.Templates = composeTemplateStats(templates, disksForTemplates, templateDisks, templateLinks, place)
+	inv.Networks = composeNetworkStats(networks, networkLinks, nicUsage, hosts, place)
+	inv.VnicProfiles = composeVnicProfileStats(profiles, networkLinks, nicUsage)
+
+	inv.setEngine(e.name)
+	return inv, nil
+}
+
+// func setEngine - tag every row of the inventory with the engine name
+func (inv *inventory) setEngine(name string) {
+	for i := range inv.VMs {
+		inv.VMs[i].Engine = name
+	}
+	for i := range inv.Disks {
+		inv.Disks[i].Engine = name
+	}
+	for i := range inv.StorageDomains {
+		inv.StorageDomains[i].Engine = name
+	}
+	for i := range inv.Hosts {
+		inv.Hosts[i].Engine = name
+	}
+	for i := range inv.Clusters {
+		inv.Clusters[i].Engine = name
+	}
+	for i := range inv.DataCenters {
+		inv.DataCenters[i].Engine = name
+	}
+	for i := range inv.Templates {
+		inv.Templates[i].Engine = name
+	}
+	for i := range inv.Snapshots {
+		inv.Snapshots[i].Engine = name
+	}
+	for i := range inv.Networks {
+		inv.Networks[i].Engine = name
+	}
+	for i := range inv.VnicProfiles {
+		inv.VnicProfiles[i].Engine = name
+	}
+}
+
+// func vmsDisks - disks and disk attachments of the page of VMs.
+//
+// In fetchFollow mode they come with VMs, in fetchSeparate mode disk attachments are fetched
+// with a request per VM and diskList is the list of all disks.
+func (e *engine) vmsDisks(ctx context.Context, vms []Vm, diskList []Disk) ([]Disk, []vmDisks, error) {
+	if e.fetchMode != fetchSeparate {
+		pageDisks, disksForVms := linkedDisks(vms)
+		return pageDisks, disksForVms, nil
+	}
+
+	disksForVms, err := diskAttachments(ctx, e.client, e.apiURL, vms, e.parallelism)
+	// Stats of the failed VMs are composed without disks.
+	if err := e.logVmErrors(ctx, "disks", err); err != nil {
+		return nil, nil, err
+	}
+	return diskList, disksForVms, nil
+}
+
+// func hosts - hosts with their network attachments.
+//
+// In fetchFollow mode network attachments come with hosts, in fetchSeparate mode they are fetched
+// with a request per host.
+func (e *engine) hosts(ctx context.Context) ([]Host, error) {
+	if e.fetchMode != fetchSeparate {
+		return hostsList(ctx, e.client, e.apiURL, hostFollowLinks, e.pageSize)
+	}
+	hosts, err := hostsList(ctx, e.client, e.apiURL, "", e.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	hostsNetworkAttachments(ctx, e.client, e.apiURL, hosts, e.parallelism)
+	return hosts, nil
+}
+
+// func nics - NICs and reported devices of the page of VMs.
+//
+// In fetchFollow mode they come with VMs, in fetchSeparate mode they are fetched with requests per VM.
+// Failed VMs are logged and composed without NICs.
+func (e *engine) nics(ctx context.Context, vms []Vm) ([]vmNics, error) {
+	if e.fetchMode != fetchSeparate {
+		return linkedNics(vms), nil
+	}
+	nicsForVms, err := vmsNics(ctx, e.client, e.apiURL, vms, e.parallelism)
+	if err := e.logVmErrors(ctx, "nics", err); err != nil {
+		return nil, err
+	}
+	return nicsForVms, nil
+}
+
+// func snapshots - snapshots of the page of VMs, fetched with a request per VM.
+// Failed VMs are logged and composed without snapshots.
+func (e *engine) snapshots(ctx context.Context, vms []Vm) ([]vmSnapshots, error) {
+	snapshotsForVms, err := vmsSnapshots(ctx, e.client, e.apiURL, vms, e.fetchMode != fetchSeparate, e.parallelism)
+	if err := e.logVmErrors(ctx, "snapshots", err); err != nil {
+		return nil, err
+	}
+	return snapshotsForVms, nil
+}
+
+// func logVmErrors - log VMs failed to fetch what of, errors other than vmErrors are returned as is.
+// Once ctx is done every VM fails, the error of ctx is returned instead of logging them.
+func (e *engine) logVmErrors(ctx context.Context, what string, err error) error {
+	var failed vmErrors
+	if !errors.As(err, &failed) {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, vmErr := range failed {
+		log.Printf("engine %s: %s: %v", e.name, what, vmErr)
+	}
+	return nil
+}
+
+// func templatesDisks - disks and disk attachments of templates.
+//
+// In fetchFollow mode they come with templates, in fetchSeparate mode disk attachments are fetched
+// with a request per template, up to e.parallelism concurrently, and diskList is the list of all disks.
+// Templates failed to fetch disk attachments of are logged and composed without disks.
+func (e *engine) templatesDisks(ctx context.Context, templates []Template, diskList []Disk) ([]Disk, []vmDisks) {
+	if e.fetchMode != fetchSeparate {
+		return linkedTemplateDisks(templates)
+	}
+	var fetched []Template
+	for _, t := range templates {
+		if t.ID != blankTemplateID {
+			fetched = append(fetched, t)
+		}
+	}
+	results, errs := forEach(fetched, e.parallelism, func(t Template) ([]DiskAttachment, error) {
+		return getCollection[DiskAttachments](ctx, e.client, e.apiURL+"/templates/"+t.ID+"/diskattachments")
+	})
+	var disksForTemplates = make([]vmDisks, 0, len(fetched))
+	for i, t := range fetched {
+		if errs[i] != nil {
+			log.Printf("engine %s: template %s (%s): %v", e.name, t.Name, t.ID, errs[i])
+			continue
+		}
+		disksForTemplates = append(disksForTemplates, vmDisks{vmID: t.ID, diskAttachments: results[i]})
+	}
+	return diskList, disksForTemplates
+}
+
+// func inventoryEngines - inventory all engines once, see engineSet.inventory
+func inventoryEngines(ctx context.Context, cfg *config, sink rowSink) (*inventory, []string) {
+	return newEngineSet(cfg).inventory(ctx, sink)
+}
+
+// Engines of the config, reused by every inventory. An engine is connected by the first inventory,
+// an engine failed to connect or refused the token is connected again by the next one.
+// Inventories of the set must not run concurrently.
+type engineSet struct {
+	cfg     *config
+	configs []engineConfig
+	engines []*engine // Connected engines in the order of configs, nil if not connected.
+}
+
+func newEngineSet(cfg *config) *engineSet {
+	configs := cfg.engines()
+	return &engineSet{cfg: cfg, configs: configs, engines: make([]*engine, len(configs))}
+}
+
+// func inventory - inventory all engines concurrently and merge inventories in the order of engines.
+// MAC addresses are checked for duplicates across all engines.
+//
+// A failure on one engine is logged and doesn't abort the others, names of failed engines are returned.
+// Once ctx is done requests in flight and waits between retries are aborted. Engines connected by
+// the inventory keep ctx to log in again when their tokens expire. Rows are passed to sink
+// while they are collected, see engine.inventory, sink may be nil.
+func (s *engineSet) inventory(ctx context.Context, sink rowSink) (*inventory, []string) {
+	results := make([]*inventory, len(s.configs))
+	errs := make([]error, len(s.configs))
+
+	var wg sync.WaitGroup
+	for i, ec := range s.configs {
+		wg.Add(1)
+		go func(i int, ec engineConfig) {
+			defer wg.Done()
+			if s.engines[i] == nil {
+				e, err := newEngine(ctx, ec, s.cfg)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				s.engines[i] = e
+			}
+			results[i], errs[i] = s.engines[i].inventory(ctx, sink)
+			if errors.Is(errs[i], errAuthentication) {
+				s.engines[i] = nil
+			}
+		}(i, ec)
+	}
+	wg.Wait()
+
+	inv := &inventory{}
+	var failed []string
+	for i, ec := range s.configs {
+		if errs[i] != nil {
+			logEngineError(ec.Name, errs[i])
+			failed = append(failed, ec.Name)
+			continue
+		}
+		inv.VMs = append(inv.VMs, results[i].VMs...)
+		inv.Disks = append(inv.Disks, results[i].Disks...)
+		inv.StorageDomains = append(inv.StorageDomains, results[i].StorageDomains...)
+		inv.Hosts = append(inv.Hosts, results[i].Hosts...)
+		inv.Clusters = append(inv.Clusters, results[i].Clusters...)
+		inv.DataCenters = append(inv.DataCenters, results[i].DataCenters...)
+		inv.Templates = append(inv.Templates, results[i].Templates...)
+		inv.Snapshots = append(inv.Snapshots, results[i].Snapshots...)
+		inv.Networks = append(inv.Networks, results[i].Networks...)
+		inv.VnicProfiles = append(inv.VnicProfiles, results[i].VnicProfiles...)
+	}
+	inv.DuplicateMACs = findDuplicateMACs(inv.VMs)
+	return inv, failed
+}
+
+// func logEngineError - log the engine failure with a hint depending on the class of error
+func logEngineError(name string, err error) {
+	switch {
+	case errors.Is(err, errAuthentication):
+		log.Printf("engine %s: check user, password and scope: %v", name, err)
+	case errors.Is(err, errBadRequest):
+		log.Printf("engine %s: check search query: %v", name, err)
+	case errors.Is(err, errNotFound):
+		log.Printf("engine %s: check engine address and API version: %v", name, err)
+	case errors.Is(err, errServer), errors.Is(err, errCircuitOpen):
+		log.Printf("engine %s: engine is unavailable: %v", name, err)
+	default:
+		log.Printf("engine %s: %v", name, err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestPasswordTokenSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		expiresIn  int
+		wantLogins int
+	}{
+		{"valid token is reused", 3600, 1},
+		{"expired token is replaced with a new login", 1, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logins := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case tokenURL:
+					if r.FormValue("grant_type") != "password" || r.FormValue("password") != "secret" {
+						t.Errorf("token request %v, want password grant", r.Form)
+					}
+					logins++
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprintf(w, `{"access_token":"token%d","token_type":"bearer","expires_in":%d}`, logins, tt.expiresIn)
+				default:
+					if got, want := r.Header.Get("Authorization"), fmt.Sprintf("Bearer token%d", logins); got != want {
+						t.Errorf("Authorization = %q, want %q", got, want)
+					}
+				}
+			}))
+			defer server.Close()
+
+			conf := &oauth2.Config{
+				ClientID:     "admin@internal",
+				ClientSecret: "secret",
+				Endpoint:     oauth2.Endpoint{TokenURL: server.URL + tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+			}
+			ctx := context.WithValue(context.Background(), oauth2.HTTPClient, server.Client())
+			source := &passwordTokenSource{ctx: ctx, conf: conf, name: "engine1"}
+			token, err := source.Token()
+			if err != nil {
+				t.Fatalf("Token() error = %v", err)
+			}
+			client := oauth2.NewClient(ctx, oauth2.ReuseTokenSource(token, source))
+			for i := 0; i < 2; i++ {
+				resp, err := client.Get(server.URL + apiUrl)
+				if err != nil {
+					t.Fatalf("GET error = %v", err)
+				}
+				resp.Body.Close()
+			}
+			if logins != tt.wantLogins {
+				t.Errorf("logins = %d, want %d", logins, tt.wantLogins)
+			}
+		})
+	}
+}
+
+func TestPasswordTokenSourceRefused(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"access_denied","error_description":"Cannot authenticate user"}`)
+	}))
+	defer server.Close()
+
+	conf := &oauth2.Config{
+		ClientID: "admin@internal",
+		Endpoint: oauth2.Endpoint{TokenURL: server.URL + tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, server.Client())
+	source := &passwordTokenSource{ctx: ctx, conf: conf, name: "engine1"}
+	if _, err := source.Token(); !errors.Is(err, errAuthentication) {
+		t.Errorf("Token() error = %v, want errAuthentication", err)
+	}
+}
+
+// func fakeEngine - engine connected to an API server with a VM and empty other collections
+func fakeEngine(t *testing.T, name, vm string, status int) *engine {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if r.URL.Path == apiUrl+"/vms" {
+			fmt.Fprintf(w, `{"vm":[{"id":"%s-id","name":"%s","status":"up"}]}`, vm, vm)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	t.Cleanup(server.Close)
+	classifier, err := newTierClassifier(tiersConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &engine{
+		name:        name,
+		apiURL:      server.URL + apiUrl,
+		client:      server.Client(),
+		parallelism: 1,
+		fetchMode:   fetchFollow,
+		classifier:  classifier,
+	}
+}
+
+func TestEngineSetInventory(t *testing.T) {
+	cfg := &config{Engines: []engineConfig{{Name: "prod"}, {Name: "dr"}, {Name: "test"}}}
+	set := newEngineSet(cfg)
+	set.engines[0] = fakeEngine(t, "prod", "web01", http.StatusOK)
+	set.engines[1] = fakeEngine(t, "dr", "web02", http.StatusUnauthorized)
+	set.engines[2] = fakeEngine(t, "test", "web03", http.StatusOK)
+
+	var mu sync.Mutex
+	var streamed []string
+	inv, failed := set.inventory(context.Background(), func(report string, rows any) error {
+		if report != "vms" {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, vm := range rows.([]vmStats) {
+			streamed = append(streamed, vm.Engine+"/"+vm.Name)
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(failed, []string{"dr"}) {
+		t.Errorf("failed = %v, want [dr]", failed)
+	}
+	var vms []string
+	for _, vm := range inv.VMs {
+		vms = append(vms, vm.Engine+"/"+vm.Name)
+	}
+	if want := []string{"prod/web01", "test/web03"}; !reflect.DeepEqual(vms, want) {
+		t.Errorf("VMs = %v, want %v in the order of engines", vms, want)
+	}
+	// Engines are collected concurrently, their pages are streamed in any order.
+	sort.Strings(streamed)
+	if want := []string{"prod/web01", "test/web03"}; !reflect.DeepEqual(streamed, want) {
+		t.Errorf("streamed VMs = %v, want %v", streamed, want)
+	}
+	// The engine refused the token is connected again by the next inventory, the others are reused.
+	if set.engines[0] == nil || set.engines[1] != nil || set.engines[2] == nil {
+		t.Errorf("connected engines = %v, want all but dr", set.engines)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Classes of errors returned by the engine, check them with errors.Is.
+var (
+	errAuthentication = errors.New("authentication failed")
+	errBadRequest     = errors.New("request refused by engine")
+	errNotFound       = errors.New("resource not found")
+	errServer         = errors.New("engine server error")
+	// Requests to the engine are suspended after too many failures, see breakerTransport.
+	errCircuitOpen = errors.New("circuit breaker is open, engine is not requested")
+)
+
+// Error response of the engine API.
+type apiError struct {
+	StatusCode int    // HTTP status code of the response.
+	URL        string // Requested URL.
+	Fault      Fault  // Parsed fault from the response body, empty if the body isn't an oVirt fault.
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("GET %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Fault.Reason != "" {
+		msg += ": " + e.Fault.Reason
+	}
+	if e.Fault.Detail != "" {
+		msg += ": " + e.Fault.Detail
+	}
+	return msg
+}
+
+// func Is - match the error against the classes errAuthentication, errBadRequest, errNotFound and errServer
+func (e *apiError) Is(target error) bool {
+	switch target {
+	case errAuthentication:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case errBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case errNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case errServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// func newAPIError - make error of the failed response, body is parsed as oVirt fault if possible
+func newAPIError(resp *http.Response, body []byte) *apiError {
+	e := &apiError{
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.String(),
+	}
+	// Authentication errors and errors of the web server in front of the engine come as HTML pages.
+	_ = json.Unmarshal(body, &e.Fault)
+	return e
+}
+
+// Failure of a single VM, it doesn't abort the inventory of the other VMs.
+type vmError struct {
+	VmID string
+	Name string
+	Err  error
+}
+
+func (e vmError) Error() string {
+	return fmt.Sprintf("VM %s (%s): %v", e.Name, e.VmID, e.Err)
+}
+
+func (e vmError) Unwrap() error { return e.Err }
+
+// Failures of VMs collected during the inventory of an engine.
+type vmErrors []vmError
+
+func (e vmErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%d VMs failed, first: %v", len(e), e[0])
+}
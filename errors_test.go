@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{errAuthentication, errBadRequest, errNotFound, errServer}
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, errAuthentication},
+		{http.StatusForbidden, errAuthentication},
+		{http.StatusBadRequest, errBadRequest},
+		{http.StatusNotFound, errNotFound},
+		{http.StatusInternalServerError, errServer},
+		{http.StatusServiceUnavailable, errServer},
+		{http.StatusConflict, nil},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			// Wrapped the same way as the engine inventory wraps errors.
+			err := fmt.Errorf("VMs: %w", &apiError{StatusCode: tt.status, URL: "https://ovirt/api/vms"})
+			for _, s := range sentinels {
+				if got := errors.Is(err, s); got != (s == tt.want) {
+					t.Errorf("errors.Is(%d, %v) = %v, want %v", tt.status, s, got, !got)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "oVirt fault",
+			body: `{"reason":"Operation Failed","detail":"Entity not found: vm1"}`,
+			want: "GET /ovirt-engine/api/vms/vm1: 404 Not Found: Operation Failed: Entity not found: vm1",
+		},
+		{
+			name: "HTML page",
+			body: `<html><body>Not Found</body></html>`,
+			want: "GET /ovirt-engine/api/vms/vm1: 404 Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			_, err := getRequest(context.Background(), server.Client(), server.URL+"/ovirt-engine/api/vms/vm1")
+			var ae *apiError
+			if !errors.As(err, &ae) {
+				t.Fatalf("getRequest() error = %v, want *apiError", err)
+			}
+			if want := server.URL + "/ovirt-engine/api/vms/vm1"; ae.URL != want {
+				t.Errorf("URL = %q, want %q", ae.URL, want)
+			}
+			ae.URL = "/ovirt-engine/api/vms/vm1"
+			if ae.Error() != tt.want {
+				t.Errorf("Error() = %q, want %q", ae.Error(), tt.want)
+			}
+			if !errors.Is(err, errNotFound) {
+				t.Error("errors.Is(err, errNotFound) = false, want true")
+			}
+		})
+	}
+}
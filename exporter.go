@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Modes of the app.
+const (
+	// The inventory is collected once and written to the output.
+	modeReport = "report"
+	// The inventory is collected on the refresh interval and served as Prometheus metrics, see exporter.
+	modeExporter = "exporter"
+)
+
+const (
+	defaultListen          = ":9813"
+	defaultRefreshInterval = 5 * time.Minute
+)
+
+// Path of the metrics in exporter mode.
+const metricsPath = "/metrics"
+
+// Options of the exporter mode.
+type exporterConfig struct {
+	Listen          string        `yaml:"listen" toml:"listen"`                     // Address the HTTP server listens on, host:port.
+	RefreshInterval time.Duration `yaml:"refresh_interval" toml:"refresh_interval"` // Interval of inventory collections.
+}
+
+func (c exporterConfig) validate() error {
+	if c.Listen == "" {
+		return errors.New("exporter listen address is not set")
+	}
+	if c.RefreshInterval <= 0 {
+		return errors.New("exporter refresh interval must be positive")
+	}
+	return nil
+}
+
+// Prometheus exporter of the inventory.
+//
+// The inventory is collected in the background on the refresh interval and cached, scrapes are served
+// from the cache, so the engines are requested once per interval whatever the count of scrapes.
+// Engines are logged in once and reused by every collection.
+type exporter struct {
+	collect func(context.Context) (*inventory, []string) // Collects the inventory, returns names of failed engines.
+	engines []string                                     // Names of all engines, not modified after start.
+	refresh time.Duration                                // Interval of collections.
+
+	mu          sync.RWMutex
+	inv         *inventory     // The last collected inventory, nil before the first success.
+	lastSuccess time.Time      // End of the last collection with at least one engine succeeded.
+	duration    time.Duration  // Duration of the last collection.
+	collections int            // Count of collections.
+	errors      map[string]int // Count of failed collections per engine.
+}
+
+func newExporter(cfg *config) *exporter {
+	engines := newEngineSet(cfg)
+	x := &exporter{
+		collect: func(ctx context.Context) (*inventory, []string) { return engines.inventory(ctx, nil) },
+		refresh: cfg.Exporter.RefreshInterval,
+		errors:  make(map[string]int),
+	}
+	for _, e := range cfg.engines() {
+		x.engines = append(x.engines, e.Name)
+	}
+	return x
+}
+
+// func runExporter - serve metrics and collect the inventory on the refresh interval until ctx is done
+func runExporter(ctx context.Context, cfg *config) error {
+	x := newExporter(cfg)
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, x)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, "<html><body><h1>oVirt inventory exporter</h1><a href=%q>Metrics</a></body></html>\n", metricsPath)
+	})
+	server := &http.Server{Addr: cfg.Exporter.Listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go x.run(ctx)
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+	log.Printf("exporter: serving metrics on %s%s, refresh every %v", cfg.Exporter.Listen, metricsPath, x.refresh)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// func run - collect the inventory now and then on every tick of the refresh interval until ctx is done
+func (x *exporter) run(ctx context.Context) {
+	ticker := time.NewTicker(x.refresh)
+	defer ticker.Stop()
+	for {
+		x.collectOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// func collectOnce - collect the inventory and cache it, unless all engines failed
+//
+// If some engines failed the cached inventory holds the others only, their metrics disappear until
+// the next successful collection.
+func (x *exporter) collectOnce(ctx context.Context) {
+	start := time.Now()
+	inv, failed := x.collect(ctx)
+	duration := time.Since(start)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.collections++
+	x.duration = duration
+	for _, name := range failed {
+		x.errors[name]++
+	}
+	if len(failed) < len(x.engines) {
+		x.inv = inv
+		x.lastSuccess = time.Now()
+	}
+}
+
+// func ServeHTTP - serve the cached metrics in Prometheus text format
+func (x *exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	x.writeMetrics(bw)
+	if err := bw.Flush(); err != nil {
+		log.Printf("exporter: %v", err)
+	}
+}
+
+// func writeMetrics - write metrics of the exporter and of the cached inventory
+//
+// The state is copied under the read lock and rendered after it is released, so a slow scrape
+// doesn't hold up caching of the next collection. A cached inventory is never modified, only replaced.
+func (x *exporter) writeMetrics(w io.Writer) {
+	x.mu.RLock()
+	inv, lastSuccess, duration, collections := x.inv, x.lastSuccess, x.duration, x.collections
+	failures := make([]int, len(x.engines))
+	for i, name := range x.engines {
+		failures[i] = x.errors[name]
+	}
+	x.mu.RUnlock()
+
+	m := metricsWriter{w: w}
+	m.family("ovirt_inventory_scrape_duration_seconds", "Duration of the last collection of the inventory from the engines.", "gauge")
+	m.sample("ovirt_inventory_scrape_duration_seconds", nil, duration.Seconds())
+	m.family("ovirt_inventory_scrapes_total", "Count of collections of the inventory.", "counter")
+	m.sample("ovirt_inventory_scrapes_total", nil, float64(collections))
+	m.family("ovirt_inventory_scrape_errors_total", "Count of failed collections of the inventory, per engine.", "counter")
+	for i, name := range x.engines {
+		m.sample("ovirt_inventory_scrape_errors_total", []string{"engine", name}, float64(failures[i]))
+	}
+	m.family("ovirt_inventory_last_success_timestamp_seconds", "Time of the last successful collection, 0 before the first one.", "gauge")
+	var last float64
+	if !lastSuccess.IsZero() {
+		last = float64(lastSuccess.UnixMilli()) / 1000
+	}
+	m.sample("ovirt_inventory_last_success_timestamp_seconds", nil, last)
+	if inv != nil {
+		writeInventoryMetrics(&m, inv)
+	}
+}
+
+// func writeInventoryMetrics - write gauges of VMs, storage domains and hosts
+func writeInventoryMetrics(m *metricsWriter, inv *inventory) {
+	vmLabels := func(vm vmStats, extra ...string) []string {
+		return append([]string{"engine", vm.Engine, "data_center", vm.DataCenter, "cluster", vm.Cluster, "vm", vm.Name}, extra...)
+	}
+	m.family("ovirt_vm_info", "Information about the virtual machine, always 1.", "gauge")
+	for _, vm := range inv.VMs {
+		m.sample("ovirt_vm_info", vmLabels(vm, "id", vm.ID, "host", vm.Host, "os", vm.OS, "template", vm.Template), 1)
+	}
+	m.family("ovirt_vm_status", "Status of the virtual machine, 1 with the current status label.", "gauge")
+	for _, vm := range inv.VMs {
+		m.sample("ovirt_vm_status", vmLabels(vm, "status", string(vm.Status)), 1)
+	}
+	m.family("ovirt_vm_vcpus", "Virtual CPUs of the virtual machine.", "gauge")
+	for _, vm := range inv.VMs {
+		m.sample("ovirt_vm_vcpus", vmLabels(vm), float64(vm.Cpu))
+	}
+	m.family("ovirt_vm_memory_bytes", "Memory of the virtual machine.", "gauge")
+	for _, vm := range inv.VMs {
+		m.sample("ovirt_vm_memory_bytes", vmLabels(vm), float64(vm.Memory))
+	}
+	m.family("ovirt_vm_disk_provisioned_bytes", "Virtual size of disks of the virtual machine, per storage tier.", "gauge")
+	for _, vm := range inv.VMs {
+		for _, tier := range sortedKeys(vm.DiskSizeByTier) {
+			m.sample("ovirt_vm_disk_provisioned_bytes", vmLabels(vm, "tier", tier), float64(vm.DiskSizeByTier[tier]))
+		}
+	}
+	m.family("ovirt_vm_disk_actual_bytes", "Actual size of disks of the virtual machine.", "gauge")
+	for _, vm := range inv.VMs {
+		m.sample("ovirt_vm_disk_actual_bytes", vmLabels(vm), float64(vm.ActualSize))
+	}
+
+	domainLabels := func(d storageDomainStats) []string {
+		return []string{"engine", d.Engine, "storage_domain", d.Name, "type", string(d.Type), "tier", d.Tier}
+	}
+	for _, g := range []struct {
+		name, help string
+		value      func(storageDomainStats) int
+	}{
+		{"ovirt_storage_domain_available_bytes", "Free space of the storage domain.", func(d storageDomainStats) int { return d.Available }},
+		{"ovirt_storage_domain_used_bytes", "Used space of the storage domain.", func(d storageDomainStats) int { return d.Used }},
+		{"ovirt_storage_domain_committed_bytes", "Space of the storage domain committed to disks.", func(d storageDomainStats) int { return d.Committed }},
+		{"ovirt_storage_domain_total_bytes", "Capacity of the storage domain.", func(d storageDomainStats) int { return d.Total }},
+	} {
+		m.family(g.name, g.help, "gauge")
+		for _, d := range inv.StorageDomains {
+			m.sample(g.name, domainLabels(d), float64(g.value(d)))
+		}
+	}
+
+	hostLabels := func(h hostStats) []string {
+		return []string{"engine", h.Engine, "data_center", h.DataCenter, "cluster", h.Cluster, "host", h.Name}
+	}
+	m.family("ovirt_host_status", "Status of the host, 1 with the current status label.", "gauge")
+	for _, h := range inv.Hosts {
+		m.sample("ovirt_host_status", append(hostLabels(h), "status", string(h.Status)), 1)
+	}
+	for _, g := range []struct {
+		name, help string
+		value      func(hostStats) int
+	}{
+		{"ovirt_host_vms_active", "Virtual machines active on the host.", func(h hostStats) int { return h.VmsActive }},
+		{"ovirt_host_vms_migrating", "Virtual machines migrating to or from the host.", func(h hostStats) int { return h.VmsMigrating }},
+		{"ovirt_host_vms_total", "Virtual machines present on the host.", func(h hostStats) int { return h.VmsTotal }},
+	} {
+		m.family(g.name, g.help, "gauge")
+		for _, h := range inv.Hosts {
+			m.sample(g.name, hostLabels(h), float64(g.value(h)))
+		}
+	}
+}
+
+// Writer of the Prometheus text format.
+type metricsWriter struct {
+	w io.Writer
+}
+
+// func family - write HELP and TYPE lines of the metric family, its samples follow
+func (m *metricsWriter) family(name, help, typ string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// func sample - write a sample, labels are name and value pairs
+func (m *metricsWriter) sample(name string, labels []string, value float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(labels[i])
+			b.WriteString(`="`)
+			b.WriteString(labelValueEscaper.Replace(labels[i+1]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte('\n')
+	io.WriteString(m.w, b.String())
+}
+
+// Escaping of label values in the Prometheus text format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// func sortedKeys - keys of the map in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
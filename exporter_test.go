@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteInventoryMetrics(t *testing.T) {
+	inv := &inventory{
+		VMs: []vmStats{{Engine: "engine1", Cluster: "prod", Name: `web"01`, Status: "up", Cpu: 2, Memory: 4 << 30,
+			DiskSizeByTier: map[string]int{"ssd": 10, "hdd": 20}}},
+		StorageDomains: []storageDomainStats{{Engine: "engine1", Name: "FC-SSD-01", Type: "data", Tier: "ssd", Total: 100}},
+		Hosts:          []hostStats{{Engine: "engine1", Cluster: "prod", Name: "host01", Status: "up", VmsActive: 3}},
+	}
+	var b strings.Builder
+	writeInventoryMetrics(&metricsWriter{w: &b}, inv)
+	metrics := b.String()
+	vm := `engine="engine1",data_center="",cluster="prod",vm="web\"01"`
+	for _, want := range []string{
+		"# TYPE ovirt_vm_status gauge\n",
+		"ovirt_vm_status{" + vm + `,status="up"} 1` + "\n",
+		"ovirt_vm_vcpus{" + vm + "} 2\n",
+		"ovirt_vm_memory_bytes{" + vm + "} 4294967296\n",
+		"ovirt_vm_disk_provisioned_bytes{" + vm + `,tier="hdd"} 20` + "\n" +
+			"ovirt_vm_disk_provisioned_bytes{" + vm + `,tier="ssd"} 10` + "\n",
+		`ovirt_storage_domain_total_bytes{engine="engine1",storage_domain="FC-SSD-01",type="data",tier="ssd"} 100` + "\n",
+		`ovirt_host_vms_active{engine="engine1",data_center="",cluster="prod",host="host01"} 3` + "\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics have no %q", want)
+		}
+	}
+}
+
+func TestExporterCollect(t *testing.T) {
+	results := []struct {
+		inv    *inventory
+		failed []string
+	}{
+		{&inventory{VMs: []vmStats{{Engine: "engine1", Name: "web01"}}}, []string{"engine2"}},
+		{&inventory{}, []string{"engine1", "engine2"}},
+	}
+	calls := 0
+	x := &exporter{
+		collect: func(context.Context) (*inventory, []string) {
+			r := results[calls]
+			calls++
+			return r.inv, r.failed
+		},
+		engines: []string{"engine1", "engine2"},
+		refresh: time.Minute,
+		errors:  make(map[string]int),
+	}
+	x.collectOnce(context.Background())
+	x.collectOnce(context.Background())
+
+	// The second collection failed on all engines, the inventory of the first one is served.
+	rec := httptest.NewRecorder()
+	x.ServeHTTP(rec, httptest.NewRequest("GET", metricsPath, nil))
+	body, _ := io.ReadAll(rec.Body)
+	metrics := string(body)
+	for _, want := range []string{
+		"ovirt_inventory_scrapes_total 2\n",
+		`ovirt_inventory_scrape_errors_total{engine="engine1"} 1` + "\n",
+		`ovirt_inventory_scrape_errors_total{engine="engine2"} 2` + "\n",
+		`ovirt_vm_vcpus{engine="engine1",data_center="",cluster="",vm="web01"} 0` + "\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics have no %q", want)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want Prometheus text format", ct)
+	}
+}
+
+func TestExporterBeforeCollection(t *testing.T) {
+	x := &exporter{engines: []string{"engine1"}, errors: make(map[string]int)}
+	var b strings.Builder
+	x.writeMetrics(&b)
+	if !strings.Contains(b.String(), "ovirt_inventory_last_success_timestamp_seconds 0\n") {
+		t.Errorf("metrics have no zero last success time:\n%s", b.String())
+	}
+	if strings.Contains(b.String(), "ovirt_vm_") {
+		t.Error("metrics have VM metrics before the first collection")
+	}
+}
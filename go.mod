@@ -2,11 +2,23 @@ module ovirt_inventory
 
 go 1.19
 
-require golang.org/x/oauth2 v0.5.0
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/xuri/excelize/v2 v2.8.1
+	golang.org/x/oauth2 v0.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/golang/protobuf v1.5.2 // indirect
-	golang.org/x/net v0.6.0 // indirect
+	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.3 // indirect
+	github.com/xuri/efp v0.0.0-20231025114914-d1ff6096ae53 // indirect
+	github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
+	golang.org/x/crypto v0.19.0 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
 )
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// func composeHostStats - compose hardware and software stats of hosts
+func composeHostStats(hosts []Host) []hostStats {
+	var hostsStats = make([]hostStats, len(hosts))
+	for i, h := range hosts {
+		hostsStats[i].ID = h.ID
+		hostsStats[i].Name = h.Name
+		hostsStats[i].Address = h.Address
+		hostsStats[i].Status = h.Status
+		hostsStats[i].Type = h.Type
+		hostsStats[i].Manufacturer = h.HardwareInformation.Manufacturer
+		hostsStats[i].Model = h.HardwareInformation.ProductName
+		hostsStats[i].SerialNumber = h.HardwareInformation.SerialNumber
+		hostsStats[i].UUID = h.HardwareInformation.UUID
+		hostsStats[i].CpuModel = h.CPU.Name
+		hostsStats[i].CpuSpeed = h.CPU.Speed
+		hostsStats[i].CpuSockets = h.CPU.Topology.Sockets
+		hostsStats[i].CpuCores = h.CPU.Topology.Sockets * h.CPU.Topology.Cores
+		hostsStats[i].CpuThreads = vCpus(h.CPU.Topology)
+		hostsStats[i].Memory = h.Memory
+		hostsStats[i].OS = hostOS(h.OS)
+		hostsStats[i].VdsmVersion = versionString(h.Version)
+		hostsStats[i].LibvirtVersion = versionString(h.LibvirtVersion)
+		hostsStats[i].SeLinux = h.SeLinux.Mode
+		hostsStats[i].KsmEnabled = h.KSM.Enabled
+		hostsStats[i].Spm = h.SPM.Status
+		hostsStats[i].UpdateAvailable = h.UpdateAvailable
+		hostsStats[i].VmsActive = h.Summary.Active
+		hostsStats[i].VmsMigrating = h.Summary.Migrating
+		hostsStats[i].VmsTotal = h.Summary.Total
+	}
+	return hostsStats
+}
+
+// func hostOS - operating system type of the host with its version, e.g. "RHEL 8.6 - 1.el8"
+func hostOS(os OperatingSystem) string {
+	return strings.TrimSpace(os.Type + " " + versionString(os.Version))
+}
+
+// func versionString - full version as reported by the host, or major.minor.build-revision if it isn't reported
+func versionString(v Version) string {
+	if v.FullVersion != "" {
+		return v.FullVersion
+	}
+	if v.Major == 0 && v.Minor == 0 && v.Build == 0 && v.Revision == 0 {
+		return ""
+	}
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Build)
+	if v.Revision != 0 {
+		s += "-" + strconv.Itoa(v.Revision)
+	}
+	return s
+}
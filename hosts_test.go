@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComposeHostStats(t *testing.T) {
+	host := Host{
+		ID:      "h1",
+		Name:    "hv01",
+		Address: "hv01.example.com",
+		Status:  "up",
+		Type:    "rhel",
+		HardwareInformation: HardwareInformation{
+			Manufacturer: "Dell Inc.",
+			ProductName:  "PowerEdge R740",
+			SerialNumber: "ABC1234",
+			UUID:         "4c4c4544-0042",
+		},
+		CPU:             Cpu{Name: "Intel(R) Xeon(R) Gold 6230", Speed: 2100, Topology: CpuTopology{Sockets: 2, Cores: 20, Threads: 2}},
+		Memory:          768 << 30,
+		OS:              OperatingSystem{Type: "RHEL", Version: Version{FullVersion: "8.6 - 1.el8"}},
+		Version:         Version{FullVersion: "vdsm-4.50.3.4-1.el8"},
+		LibvirtVersion:  Version{Major: 8, Minor: 0, Build: 0, Revision: 5},
+		SeLinux:         SeLinux{Mode: "enforcing"},
+		KSM:             Ksm{Enabled: true},
+		SPM:             Spm{Status: "spm"},
+		UpdateAvailable: true,
+		Summary:         VmSummary{Active: 12, Migrating: 1, Total: 14},
+	}
+	want := hostStats{
+		ID:              "h1",
+		Name:            "hv01",
+		Address:         "hv01.example.com",
+		Status:          "up",
+		Type:            "rhel",
+		Manufacturer:    "Dell Inc.",
+		Model:           "PowerEdge R740",
+		SerialNumber:    "ABC1234",
+		UUID:            "4c4c4544-0042",
+		CpuModel:        "Intel(R) Xeon(R) Gold 6230",
+		CpuSpeed:        2100,
+		CpuSockets:      2,
+		CpuCores:        40,
+		CpuThreads:      80,
+		Memory:          768 << 30,
+		OS:              "RHEL 8.6 - 1.el8",
+		VdsmVersion:     "vdsm-4.50.3.4-1.el8",
+		LibvirtVersion:  "8.0.0-5",
+		SeLinux:         "enforcing",
+		KsmEnabled:      true,
+		Spm:             "spm",
+		UpdateAvailable: true,
+		VmsActive:       12,
+		VmsMigrating:    1,
+		VmsTotal:        14,
+	}
+	got := composeHostStats([]Host{host})
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("composeHostStats() = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	tests := []struct {
+		name    string
+		version Version
+		want    string
+	}{
+		{"empty", Version{}, ""},
+		{"full version", Version{FullVersion: "4.50.3.4", Major: 4}, "4.50.3.4"},
+		{"numbers", Version{Major: 4, Minor: 50, Build: 3}, "4.50.3"},
+		{"revision", Version{Major: 8, Build: 0, Revision: 5}, "8.0.0-5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := versionString(tt.version); got != tt.want {
+				t.Errorf("versionString(%+v) = %q, want %q", tt.version, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+)
+
+//go:embed templates/dashboard.html
+var dashboardTemplate string
+
+// Tables of the HTML dashboard: the title, the report and its columns.
+var htmlTables = []struct {
+	title, report string
+	columns       []string
+}{
+	{"VMs", "vms", []string{"engine", "data_center", "cluster", "host", "name", "status", "os", "fqdn", "cpu", "memory",
+		"provisioned_size", "actual_size", "ipv4", "template", "creation_time", "age_days"}},
+	{"Disks", "disks", []string{"engine", "alias", "vms", "storage_domains", "tier", "format", "sparse", "shareable", "status",
+		"provisioned_size", "actual_size", "total_size"}},
+	{"Hosts", "hosts", []string{"engine", "data_center", "cluster", "name", "address", "status", "model", "os", "cpu_threads",
+		"memory", "vms_active", "vms_total", "update_available"}},
+}
+
+// Data of the dashboard template.
+type dashboard struct {
+	Generated      time.Time
+	VmsCount       int
+	HostsCount     int
+	DisksCount     int
+	StatusCounts   []statusCount
+	StorageDomains []domainCapacity
+	Tables         []htmlTable
+}
+
+// The count of VMs in a status.
+type statusCount struct {
+	Status  VmStatus
+	Count   int
+	Percent float64
+}
+
+// Capacity of a storage domain for its bar.
+type domainCapacity struct {
+	storageDomainStats
+	UsedText, TotalText string
+}
+
+// A sortable table of a report.
+type htmlTable struct {
+	ID, Title string
+	Header    []string
+	Rows      [][]htmlCell
+}
+
+// A cell of a table, sizes are shown human readable and sorted by their value.
+type htmlCell struct {
+	Text string
+	Sort string
+}
+
+// func writeHTML - write the inventory as a self-contained HTML dashboard, styles and scripts are inline
+func writeHTML(w io.Writer, inv *inventory, now time.Time) error {
+	tmpl, err := template.New("dashboard").Funcs(template.FuncMap{"percent": htmlPercent}).Parse(dashboardTemplate)
+	if err != nil {
+		return err
+	}
+	data := dashboard{
+		Generated:    now.UTC(),
+		VmsCount:     len(inv.VMs),
+		HostsCount:   len(inv.Hosts),
+		DisksCount:   len(inv.Disks),
+		StatusCounts: vmStatusCounts(inv.VMs),
+	}
+	for _, d := range inv.StorageDomains {
+		data.StorageDomains = append(data.StorageDomains, domainCapacity{d, humanSize(d.Used), humanSize(d.Total)})
+	}
+	for _, t := range htmlTables {
+		table, err := newHTMLTable(inv, t.title, t.report, t.columns)
+		if err != nil {
+			return err
+		}
+		data.Tables = append(data.Tables, table)
+	}
+	return tmpl.Execute(w, data)
+}
+
+// func vmStatusCounts - the count of VMs per status, the most frequent status first
+func vmStatusCounts(vms []vmStats) []statusCount {
+	counts := make(map[VmStatus]int)
+	for _, vm := range vms {
+		counts[vm.Status]++
+	}
+	statuses := make([]statusCount, 0, len(counts))
+	for status, count := range counts {
+		statuses = append(statuses, statusCount{Status: status, Count: count, Percent: percent(count, len(vms))})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Count != statuses[j].Count {
+			return statuses[i].Count > statuses[j].Count
+		}
+		return statuses[i].Status < statuses[j].Status
+	})
+	return statuses
+}
+
+func newHTMLTable(inv *inventory, title, report string, names []string) (htmlTable, error) {
+	columns, err := selectColumns(report, names)
+	if err != nil {
+		return htmlTable{}, err
+	}
+	table := htmlTable{ID: report, Title: title, Header: make([]string, len(columns))}
+	for i, c := range columns {
+		table.Header[i] = columnTitle(c.name)
+	}
+	rows := reportRows(inv, report)
+	for i := 0; i < rows.Len(); i++ {
+		row := make([]htmlCell, len(columns))
+		for j, c := range columns {
+			if row[j], err = newHTMLCell(rows.Index(i).FieldByIndex(c.index), sizeColumns[c.name]); err != nil {
+				return htmlTable{}, err
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func newHTMLCell(v reflect.Value, size bool) (htmlCell, error) {
+	if t, ok := v.Interface().(Timestamp); ok {
+		if t.IsZero() {
+			return htmlCell{}, nil
+		}
+		return htmlCell{Text: t.Format("2006-01-02 15:04"), Sort: t.Format(time.RFC3339)}, nil
+	}
+	text, err := csvValue(v)
+	if err != nil {
+		return htmlCell{}, err
+	}
+	if size {
+		return htmlCell{Text: humanSize(int(v.Int())), Sort: text}, nil
+	}
+	return htmlCell{Text: text}, nil
+}
+
+// func humanSize - size in bytes with a binary unit, e.g. 1.5 GiB
+func humanSize(bytes int) string {
+	const units = "KMGTPE"
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size, unit := float64(bytes)/1024, 0
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %ciB", size, units[unit])
+}
+
+// func htmlPercent - percent as a CSS width
+func htmlPercent(p float64) template.CSS {
+	if p > 100 {
+		p = 100
+	}
+	return template.CSS(fmt.Sprintf("%.1f%%", p))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTML(t *testing.T) {
+	inv := testInventory()
+	inv.VMs[0].Status = "up"
+	inv.VMs[0].Memory = 4 << 30
+	inv.VMs = append(inv.VMs, vmStats{Name: "<db01>", Status: "down"}, vmStats{Name: "db02", Status: "up"})
+	inv.StorageDomains = []storageDomainStats{{Name: "FC-SSD-01", Used: 91 << 30, Total: 100 << 30, UsedPercent: 91, LowSpace: true}}
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := writeHTML(&buf, inv, now); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		"Generated 2023-03-10 12:00 UTC",
+		"<span>3 VMs</span>",
+		`<tr><td>up</td><td>2</td><td>66.7 %</td>`,
+		`<td>FC-SSD-01</td>`,
+		`<div class="low"><div style="width: 91.0%">`,
+		`<td data-sort="4294967296">4.0 GiB</td>`,
+		`<td data-sort="2023-02-17T09:30:00Z">2023-02-17 09:30</td>`,
+		"&lt;db01&gt;",
+		`<table class="report" id="disks">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("writeHTML() has no %q", want)
+		}
+	}
+	if strings.Contains(page, "<db01>") {
+		t.Error("writeHTML() doesn't escape names")
+	}
+}
+
+func TestVmStatusCounts(t *testing.T) {
+	vms := []vmStats{{Status: "down"}, {Status: "up"}, {Status: "up"}, {Status: "paused"}}
+	want := []statusCount{{"up", 2, 50}, {"down", 1, 25}, {"paused", 1, 25}}
+	if got := vmStatusCounts(vms); !reflect.DeepEqual(got, want) {
+		t.Errorf("vmStatusCounts() = %v, want %v", got, want)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int]string{
+		0:               "0 B",
+		1536:            "1.5 KiB",
+		10 << 30:        "10.0 GiB",
+		3<<40 + 512<<30: "3.5 TiB",
+	}
+	for bytes, want := range tests {
+		if got := humanSize(bytes); got != want {
+			t.Errorf("humanSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
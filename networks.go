@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log"
+	"sort"
+)
+
+// Networks, vNIC profiles and QoS definitions of an engine by ID.
+type networkIndex struct {
+	networks map[string]Network
+	profiles map[string]VnicProfile
+	qos      map[string]string // QoS ID to name.
+}
+
+// func newNetworkIndex - index networks, vNIC profiles and QoS definitions by ID
+func newNetworkIndex(networks []Network, profiles []VnicProfile, qoss []Qos) networkIndex {
+	n := networkIndex{
+		networks: make(map[string]Network, len(networks)),
+		profiles: make(map[string]VnicProfile, len(profiles)),
+		qos:      make(map[string]string, len(qoss)),
+	}
+	for _, net := range networks {
+		n.networks[net.ID] = net
+	}
+	for _, p := range profiles {
+		n.profiles[p.ID] = p
+	}
+	for _, q := range qoss {
+		n.qos[q.ID] = q.Name
+	}
+	return n
+}
+
+// VM NICs on networks and vNIC profiles of an engine.
+type networkUsage struct {
+	nics map[string]int      // Network or vNIC profile ID to the count of NICs.
+	vms  map[string][]string // Network or vNIC profile ID to names of VMs.
+	seen map[string]bool     // Network or vNIC profile ID with VM ID already listed.
+
+	partial bool // Only VMs matched by a search query are counted, a network without them may still be in use.
+}
+
+// func newNetworkUsage - usage of networks by VMs, partial if the VMs are limited by a search query
+func newNetworkUsage(partial bool) *networkUsage {
+	return &networkUsage{
+		partial: partial,
+		nics:    make(map[string]int),
+		vms:     make(map[string][]string),
+		seen:    make(map[string]bool),
+	}
+}
+
+// func add - count NICs of VMs on their vNIC profiles and networks
+func (u *networkUsage) add(vms []Vm, nicsForVms []vmNics, networks networkIndex) {
+	vmsNicsMap := make(map[string][]Nic, len(nicsForVms))
+	for _, n := range nicsForVms {
+		vmsNicsMap[n.vmID] = n.nics
+	}
+	for _, vm := range vms {
+		for _, nic := range vmsNicsMap[vm.ID] {
+			profileID := nic.VnicProfile.ID
+			if profileID == "" {
+				// The NIC is not connected to any network.
+				continue
+			}
+			u.addVm(profileID, vm)
+			if networkID := networks.profiles[profileID].Network.ID; networkID != "" {
+				u.addVm(networkID, vm)
+			}
+		}
+	}
+}
+
+func (u *networkUsage) addVm(id string, vm Vm) {
+	u.nics[id]++
+	if key := id + "/" + vm.ID; !u.seen[key] {
+		u.seen[key] = true
+		u.vms[id] = append(u.vms[id], vm.Name)
+	}
+}
+
+// func composeNetworkStats - compose stats of networks with VMs and hosts using them.
+// A network is unused if no VM NIC is on it and no host has it attached, with partial usage it is never flagged.
+func composeNetworkStats(networks []Network, index networkIndex, usage *networkUsage, hosts []Host, p placement) []networkStats {
+	hostsByNetwork := make(map[string][]string)
+	for _, h := range hosts {
+		for _, attachment := range h.NetworkAttachments {
+			hostsByNetwork[attachment.Network.ID] = append(hostsByNetwork[attachment.Network.ID], h.Name)
+		}
+	}
+	profilesByNetwork := make(map[string][]string)
+	for _, profile := range index.profiles {
+		profilesByNetwork[profile.Network.ID] = append(profilesByNetwork[profile.Network.ID], profile.Name)
+	}
+
+	var networksStats = make([]networkStats, len(networks))
+	for i, n := range networks {
+		networksStats[i].ID = n.ID
+		networksStats[i].Name = n.Name
+		networksStats[i].Description = n.Description
+		networksStats[i].DataCenter = p.dataCenters[n.DataCenter.ID].Name
+		networksStats[i].Vlan = n.Vlan.ID
+		networksStats[i].Mtu = n.Mtu
+		networksStats[i].Usages = n.Usages
+		networksStats[i].Required = n.Required
+		networksStats[i].Qos = index.qos[n.Qos.ID]
+		networksStats[i].Profiles = sortedStrings(profilesByNetwork[n.ID])
+		networksStats[i].NicsCount = usage.nics[n.ID]
+		networksStats[i].VMs = usage.vms[n.ID]
+		networksStats[i].Hosts = hostsByNetwork[n.ID]
+		networksStats[i].Unused = !usage.partial && usage.nics[n.ID] == 0 && len(hostsByNetwork[n.ID]) == 0
+	}
+	return networksStats
+}
+
+// func composeVnicProfileStats - compose stats of vNIC profiles with VMs using them.
+// A profile is unused if no VM NIC uses it, with partial usage it is never flagged.
+func composeVnicProfileStats(profiles []VnicProfile, index networkIndex, usage *networkUsage) []vnicProfileStats {
+	var profilesStats = make([]vnicProfileStats, len(profiles))
+	for i, profile := range profiles {
+		network := index.networks[profile.Network.ID]
+		profilesStats[i].ID = profile.ID
+		profilesStats[i].Name = profile.Name
+		profilesStats[i].Network = network.Name
+		profilesStats[i].Vlan = network.Vlan.ID
+		profilesStats[i].Qos = index.qos[profile.Qos.ID]
+		profilesStats[i].PortMirroring = profile.PortMirroring
+		profilesStats[i].NicsCount = usage.nics[profile.ID]
+		profilesStats[i].VMs = usage.vms[profile.ID]
+		profilesStats[i].Unused = !usage.partial && usage.nics[profile.ID] == 0
+	}
+	return profilesStats
+}
+
+// func logUnusedNetworks - list networks no VM or host uses
+func logUnusedNetworks(networksStats []networkStats) {
+	for _, n := range networksStats {
+		if n.Unused {
+			log.Printf("engine %s: network %s (VLAN %d) is not used by any VM or host", n.Engine, n.Name, n.Vlan)
+		}
+	}
+}
+
+// func sortedStrings - sort the list in place and return it
+func sortedStrings(list []string) []string {
+	sort.Strings(list)
+	return list
+}
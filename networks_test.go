@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComposeNetworkStats(t *testing.T) {
+	dataCenters := []DataCenter{{ID: "dc1", Name: "Default"}}
+	networks := []Network{
+		{ID: "n1", Name: "ovirtmgmt", DataCenter: DataCenter{ID: "dc1"}, Usages: []NetworkUsage{"management", "vm"}, Required: true},
+		{ID: "n2", Name: "vlan123", DataCenter: DataCenter{ID: "dc1"}, Vlan: Vlan{ID: 123}, Mtu: 9000, Qos: Qos{ID: "q1"}},
+		{ID: "n3", Name: "vlan200", DataCenter: DataCenter{ID: "dc1"}, Vlan: Vlan{ID: 200}},
+	}
+	profiles := []VnicProfile{
+		{ID: "p1", Name: "ovirtmgmt", Network: Network{ID: "n1"}},
+		{ID: "p2", Name: "vlan123", Network: Network{ID: "n2"}},
+		{ID: "p3", Name: "vlan123-mirror", Network: Network{ID: "n2"}, PortMirroring: true, Qos: Qos{ID: "q2"}},
+		{ID: "p4", Name: "vlan200", Network: Network{ID: "n3"}},
+	}
+	qoss := []Qos{{ID: "q1", Name: "host-10g"}, {ID: "q2", Name: "limit-1g"}}
+	hosts := []Host{{ID: "h1", Name: "hv01", NetworkAttachments: []NetworkAttachment{{Network: Network{ID: "n1"}}}}}
+	vms := []Vm{{ID: "vm1", Name: "web01"}, {ID: "vm2", Name: "web02"}}
+	nicsForVms := []vmNics{
+		{vmID: "vm1", nics: []Nic{{VnicProfile: VnicProfile{ID: "p2"}}, {VnicProfile: VnicProfile{ID: "p3"}}, {}}},
+		{vmID: "vm2", nics: []Nic{{VnicProfile: VnicProfile{ID: "p2"}}}},
+	}
+
+	index := newNetworkIndex(networks, profiles, qoss)
+	usage := newNetworkUsage(false)
+	usage.add(vms, nicsForVms, index)
+	place := newPlacement(nil, dataCenters, hosts)
+
+	networksStats := composeNetworkStats(networks, index, usage, hosts, place)
+	want := []networkStats{
+		{ID: "n1", Name: "ovirtmgmt", DataCenter: "Default", Usages: []NetworkUsage{"management", "vm"}, Required: true,
+			Profiles: []string{"ovirtmgmt"}, Hosts: []string{"hv01"}},
+		{ID: "n2", Name: "vlan123", DataCenter: "Default", Vlan: 123, Mtu: 9000, Qos: "host-10g",
+			Profiles: []string{"vlan123", "vlan123-mirror"}, NicsCount: 3, VMs: []string{"web01", "web02"}},
+		{ID: "n3", Name: "vlan200", DataCenter: "Default", Vlan: 200, Profiles: []string{"vlan200"}, Unused: true},
+	}
+	if !reflect.DeepEqual(networksStats, want) {
+		t.Errorf("composeNetworkStats() = %+v, want %+v", networksStats, want)
+	}
+
+	profilesStats := composeVnicProfileStats(profiles, index, usage)
+	wantProfile := vnicProfileStats{ID: "p3", Name: "vlan123-mirror", Network: "vlan123", Vlan: 123, Qos: "limit-1g",
+		PortMirroring: true, NicsCount: 1, VMs: []string{"web01"}}
+	if !reflect.DeepEqual(profilesStats[2], wantProfile) {
+		t.Errorf("composeVnicProfileStats()[2] = %+v, want %+v", profilesStats[2], wantProfile)
+	}
+	if !profilesStats[0].Unused || profilesStats[1].Unused {
+		t.Errorf("Unused = %v %v, want true false", profilesStats[0].Unused, profilesStats[1].Unused)
+	}
+}
+
+func TestComposeNetworkStatsPartial(t *testing.T) {
+	networks := []Network{{ID: "n1", Name: "vlan200", Vlan: Vlan{ID: 200}}}
+	profiles := []VnicProfile{{ID: "p1", Name: "vlan200", Network: Network{ID: "n1"}}}
+	index := newNetworkIndex(networks, profiles, nil)
+	usage := newNetworkUsage(true)
+	usage.add([]Vm{{ID: "vm1", Name: "web01"}}, nil, index)
+
+	networksStats := composeNetworkStats(networks, index, usage, nil, newPlacement(nil, nil, nil))
+	if networksStats[0].Unused {
+		t.Error("network Unused = true, want false with partial usage")
+	}
+	profilesStats := composeVnicProfileStats(profiles, index, usage)
+	if profilesStats[0].Unused {
+		t.Error("profile Unused = true, want false with partial usage")
+	}
+}
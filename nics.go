@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// func composeNics - set NICs and guest IP addresses of VMs, vmsStats are in the order of vms.
+//
+// IP addresses reported by the guest agent are matched to NICs by MAC address.
+// Loopback and link-local addresses are skipped.
+func composeNics(vmsStats []vmStats, vms []Vm, nicsForVms []vmNics, networks networkIndex) {
+	vmsNicsMap := make(map[string]vmNics, len(nicsForVms))
+	for _, n := range nicsForVms {
+		vmsNicsMap[n.vmID] = n
+	}
+	for i, v := range vms {
+		vmNics := vmsNicsMap[v.ID]
+		ips := reportedIPs(vmNics.reportedDevices)
+		for _, nic := range vmNics.nics {
+			profile := networks.profiles[nic.VnicProfile.ID]
+			network := networks.networks[profile.Network.ID]
+			stats := nicStats{
+				Name:        nic.Name,
+				MAC:         strings.ToLower(nic.MAC.Address),
+				Interface:   nic.Interface,
+				VnicProfile: profile.Name,
+				Network:     network.Name,
+				Vlan:        network.Vlan.ID,
+				Linked:      nic.Linked,
+				Plugged:     nic.Plugged,
+			}
+			stats.IPv4, stats.IPv6 = splitIPs(ips[stats.MAC])
+			vmsStats[i].Nics = append(vmsStats[i].Nics, stats)
+		}
+		var all []netip.Addr
+		for _, device := range vmNics.reportedDevices {
+			all = append(all, parseIPs(device.Ips)...)
+		}
+		vmsStats[i].IPv4, vmsStats[i].IPv6 = splitIPs(all)
+	}
+}
+
+// func reportedIPs - IP addresses of network devices reported by the guest agent, by lower case MAC address
+func reportedIPs(devices []ReportedDevice) map[string][]netip.Addr {
+	ips := make(map[string][]netip.Addr)
+	for _, device := range devices {
+		if device.Type != "" && device.Type != "network" {
+			continue
+		}
+		mac := strings.ToLower(device.Mac.Address)
+		ips[mac] = append(ips[mac], parseIPs(device.Ips)...)
+	}
+	return ips
+}
+
+// func parseIPs - parse IP addresses, skipping invalid, loopback and link-local ones
+func parseIPs(ips []Ip) []netip.Addr {
+	var addrs []netip.Addr
+	for _, ip := range ips {
+		addr, err := netip.ParseAddr(ip.Address)
+		if err != nil || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+			continue
+		}
+		addrs = append(addrs, addr.WithZone(""))
+	}
+	return addrs
+}
+
+// func splitIPs - split addresses into IPv4 and IPv6 lists, duplicates are dropped
+func splitIPs(addrs []netip.Addr) (ipv4 []string, ipv6 []string) {
+	seen := make(map[netip.Addr]bool)
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		if addr.Is4() {
+			ipv4 = append(ipv4, addr.String())
+		} else {
+			ipv6 = append(ipv6, addr.String())
+		}
+	}
+	return ipv4, ipv6
+}
+
+// A MAC address used by several NICs.
+type duplicateMAC struct {
+	MAC  string   `json:"mac"`  // The MAC address.
+	NICs []string `json:"nics"` // NICs using the address, as engine/VM/NIC.
+}
+
+// func findDuplicateMACs - flag NICs sharing a MAC address, across all engines of the inventory
+func findDuplicateMACs(vmsStats []vmStats) []duplicateMAC {
+	type nicRef struct{ vm, nic int }
+	byMAC := make(map[string][]nicRef)
+	for i := range vmsStats {
+		for j, nic := range vmsStats[i].Nics {
+			if nic.MAC != "" {
+				byMAC[nic.MAC] = append(byMAC[nic.MAC], nicRef{i, j})
+			}
+		}
+	}
+
+	var duplicates []duplicateMAC
+	for mac, refs := range byMAC {
+		if len(refs) < 2 {
+			continue
+		}
+		d := duplicateMAC{MAC: mac}
+		for _, ref := range refs {
+			vm := &vmsStats[ref.vm]
+			vm.Nics[ref.nic].DuplicateMAC = true
+			d.NICs = append(d.NICs, vm.Engine+"/"+vm.Name+"/"+vm.Nics[ref.nic].Name)
+		}
+		duplicates = append(duplicates, d)
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].MAC < duplicates[j].MAC })
+	return duplicates
+}
+
+// func logDuplicateMACs - warn about MAC addresses used by several NICs
+func logDuplicateMACs(duplicates []duplicateMAC) {
+	for _, d := range duplicates {
+		log.Printf("MAC address %s is used by %d NICs: %s", d.MAC, len(d.NICs), strings.Join(d.NICs, ", "))
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComposeNics(t *testing.T) {
+	networks := newNetworkIndex(
+		[]Network{{ID: "n1", Name: "vlan10", Vlan: Vlan{ID: 10}}},
+		[]VnicProfile{{ID: "p1", Name: "prod-vlan10", Network: Network{ID: "n1"}}},
+		nil,
+	)
+	vms := []Vm{{ID: "vm1", Name: "web01"}, {ID: "vm2", Name: "web02"}}
+	nicsForVms := []vmNics{
+		{
+			vmID: "vm1",
+			nics: []Nic{
+				{Name: "nic1", MAC: Mac{Address: "56:6F:1A:00:00:01"}, Interface: "virtio", VnicProfile: VnicProfile{ID: "p1"}, Linked: true, Plugged: true},
+				{Name: "nic2", MAC: Mac{Address: "56:6f:1a:00:00:02"}},
+			},
+			reportedDevices: []ReportedDevice{
+				{Type: "network", Mac: Mac{Address: "56:6f:1a:00:00:01"}, Ips: []Ip{
+					{Address: "10.0.10.5", Version: "v4"},
+					{Address: "fe80::546f:1aff:fe00:1", Version: "v6"},
+					{Address: "2001:db8::5", Version: "v6"},
+				}},
+				{Type: "network", Mac: Mac{Address: "00:00:00:00:00:00"}, Ips: []Ip{{Address: "127.0.0.1"}, {Address: "172.17.0.1"}}},
+			},
+		},
+		{
+			vmID: "vm2",
+			nics: []Nic{{Name: "nic1", MAC: Mac{Address: "56:6f:1a:00:00:01"}}},
+		},
+	}
+
+	vmsStats := composeStats(vms, nil, nil, sharedDisks{}, testStorageTiers(t), time.Now())
+	composeNics(vmsStats, vms, nicsForVms, networks)
+
+	wantNics := []nicStats{
+		{Name: "nic1", MAC: "56:6f:1a:00:00:01", Interface: "virtio", VnicProfile: "prod-vlan10", Network: "vlan10", Vlan: 10, Linked: true, Plugged: true,
+			IPv4: []string{"10.0.10.5"}, IPv6: []string{"2001:db8::5"}},
+		{Name: "nic2", MAC: "56:6f:1a:00:00:02"},
+	}
+	if !reflect.DeepEqual(vmsStats[0].Nics, wantNics) {
+		t.Errorf("Nics = %+v, want %+v", vmsStats[0].Nics, wantNics)
+	}
+	if want := []string{"10.0.10.5", "172.17.0.1"}; !reflect.DeepEqual(vmsStats[0].IPv4, want) {
+		t.Errorf("IPv4 = %v, want %v", vmsStats[0].IPv4, want)
+	}
+	if want := []string{"2001:db8::5"}; !reflect.DeepEqual(vmsStats[0].IPv6, want) {
+		t.Errorf("IPv6 = %v, want %v", vmsStats[0].IPv6, want)
+	}
+
+	duplicates := findDuplicateMACs(vmsStats)
+	want := []duplicateMAC{{MAC: "56:6f:1a:00:00:01", NICs: []string{"/web01/nic1", "/web02/nic1"}}}
+	if !reflect.DeepEqual(duplicates, want) {
+		t.Errorf("findDuplicateMACs() = %+v, want %+v", duplicates, want)
+	}
+	if !vmsStats[0].Nics[0].DuplicateMAC || vmsStats[0].Nics[1].DuplicateMAC || !vmsStats[1].Nics[0].DuplicateMAC {
+		t.Errorf("DuplicateMAC flags = %v %v %v, want true false true",
+			vmsStats[0].Nics[0].DuplicateMAC, vmsStats[0].Nics[1].DuplicateMAC, vmsStats[1].Nics[0].DuplicateMAC)
+	}
+}
@@ -0,0 +1,527 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats.
+const (
+	formatJSON     = "json"
+	formatNDJSON   = "ndjson"
+	formatCSV      = "csv"
+	formatYAML     = "yaml"
+	formatXLSX     = "xlsx"
+	formatHTML     = "html"
+	formatInflux   = "influx"
+	formatGraphite = "graphite"
+)
+
+// Report written in CSV format when no report is chosen, CSV holds a single table.
+const defaultCSVReport = "vms"
+
+// Separator of list items in a CSV cell.
+const csvListSeparator = ";"
+
+// Output of the inventory.
+type outputConfig struct {
+	Format  string   `yaml:"format" toml:"format"`   // Output format: json, ndjson, csv, yaml, xlsx, html, influx or graphite.
+	File    string   `yaml:"file" toml:"file"`       // Path of the output file, empty or "-" for stdout.
+	Report  string   `yaml:"report" toml:"report"`   // Report to write, e.g. vms or hosts, empty for all reports.
+	Columns []string `yaml:"columns" toml:"columns"` // Columns of the CSV report in order, empty for all columns.
+	URL     string   `yaml:"url" toml:"url"`         // InfluxDB write endpoint (http, https) or Graphite address (tcp) to send to, instead of File.
+	Token   string   `yaml:"token" toml:"token"`     // InfluxDB API token, sent as "Authorization: Token <token>".
+	CAFile  string   `yaml:"ca_file" toml:"ca_file"` // PEM file with the CA certificate of the https URL, empty for the system CAs.
+}
+
+func (c outputConfig) validate() error {
+	switch c.Format {
+	case formatJSON, formatNDJSON, formatCSV, formatYAML, formatXLSX, formatHTML, formatInflux, formatGraphite:
+	default:
+		return fmt.Errorf("unknown output format %q, use %s, %s, %s, %s, %s, %s, %s or %s",
+			c.Format, formatJSON, formatNDJSON, formatCSV, formatYAML, formatXLSX, formatHTML, formatInflux, formatGraphite)
+	}
+	if c.URL != "" {
+		if err := c.validateURL(); err != nil {
+			return err
+		}
+	}
+	if c.CAFile != "" && !strings.HasPrefix(c.URL, "https://") {
+		return errors.New("output CA file can be set with https output url only")
+	}
+	if c.Report != "" {
+		switch c.Format {
+		case formatXLSX, formatHTML, formatInflux, formatGraphite:
+			return fmt.Errorf("report can't be chosen in %s format, it holds fixed reports", c.Format)
+		}
+		if _, ok := reportType(c.Report); !ok {
+			return fmt.Errorf("unknown report %q, use one of %s", c.Report, strings.Join(reportNames(), ", "))
+		}
+	}
+	if len(c.Columns) > 0 {
+		if c.Format != formatCSV {
+			return fmt.Errorf("columns can be chosen in %s format only", formatCSV)
+		}
+		if _, err := selectColumns(c.csvReport(), c.Columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func validateURL - the URL is sent to in influx and graphite formats only, instead of the file
+func (c outputConfig) validateURL() error {
+	if c.File != "" && c.File != "-" {
+		return errors.New("output file and url are mutually exclusive")
+	}
+	u, err := neturl.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("output url: %w", err)
+	}
+	switch {
+	case c.Format == formatInflux && (u.Scheme == "http" || u.Scheme == "https"):
+	case c.Format == formatGraphite && u.Scheme == "tcp":
+	case c.Format == formatInflux || c.Format == formatGraphite:
+		return fmt.Errorf("output url %s: use http or https scheme in %s format, tcp in %s format", u.Redacted(), formatInflux, formatGraphite)
+	default:
+		return fmt.Errorf("output url can be set in %s and %s formats only", formatInflux, formatGraphite)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("output url %s: host is not set", u.Redacted())
+	}
+	return nil
+}
+
+// func csvReport - report written in CSV format
+func (c outputConfig) csvReport() string {
+	if c.Report == "" {
+		return defaultCSVReport
+	}
+	return c.Report
+}
+
+// func writeOutput - write the inventory to the output file or stdout in the configured format,
+// or send it to the output URL
+func writeOutput(c outputConfig, inv *inventory) error {
+	if c.URL != "" {
+		return sendOutput(c, inv)
+	}
+	if c.File == "" || c.File == "-" {
+		return writeInventory(os.Stdout, c, inv)
+	}
+	f, err := os.Create(c.File)
+	if err != nil {
+		return err
+	}
+	if err := writeInventory(f, c, inv); err != nil {
+		f.Close()
+		return fmt.Errorf("output file %s: %w", c.File, err)
+	}
+	return f.Close()
+}
+
+// func writeInventory - write the inventory to w in the configured format
+//
+// JSON and YAML hold the whole inventory, or the chosen report only. NDJSON holds a row per line,
+// rows of all reports are tagged with the report name, see streamNDJSON to write them while collecting. CSV holds a single report, vms by default.
+// XLSX holds the summary sheet and sheets of main reports, see writeXLSX. HTML is the dashboard, see writeHTML.
+// Influx and Graphite hold points of VMs, hosts and storage domains, see inventoryPoints.
+func writeInventory(w io.Writer, c outputConfig, inv *inventory) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch c.Format {
+	case formatJSON:
+		err = writeJSON(bw, selectReport(inv, c.Report))
+	case formatYAML:
+		err = writeYAML(bw, selectReport(inv, c.Report))
+	case formatNDJSON:
+		err = writeNDJSON(bw, inv, c.Report)
+	case formatCSV:
+		err = writeCSV(bw, inv, c.csvReport(), c.Columns)
+	case formatXLSX:
+		err = writeXLSX(bw, inv)
+	case formatHTML:
+		err = writeHTML(bw, inv, time.Now())
+	case formatInflux:
+		err = writeInflux(bw, inventoryPoints(inv), time.Now())
+	case formatGraphite:
+		err = writeGraphite(bw, inventoryPoints(inv), time.Now())
+	default:
+		err = fmt.Errorf("unknown output format %q", c.Format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// func reportNames - names of reports, the JSON names of inventory fields
+func reportNames() []string {
+	t := reflect.TypeOf(inventory{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, jsonName(t.Field(i)))
+	}
+	return names
+}
+
+// func reportType - type of rows of the report
+func reportType(name string) (reflect.Type, bool) {
+	f, ok := fieldByJSONName(reflect.TypeOf(inventory{}), name)
+	if !ok || jsonName(f) != name {
+		return nil, false
+	}
+	return f.Type.Elem(), true
+}
+
+// func reportRows - rows of the report, a slice
+func reportRows(inv *inventory, name string) reflect.Value {
+	f, _ := fieldByJSONName(reflect.TypeOf(*inv), name)
+	return reflect.ValueOf(*inv).FieldByIndex(f.Index)
+}
+
+// func selectReport - the chosen report, or the whole inventory if no report is chosen
+func selectReport(inv *inventory, name string) any {
+	if name == "" {
+		return inv
+	}
+	return reportRows(inv, name).Interface()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// func writeYAML - write v as YAML with the same keys as JSON
+//
+// The stats types are tagged for JSON only. v is rendered to JSON first and the JSON document is
+// decoded as YAML node, it keeps the order of keys, then the node is rendered in the block style.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	clearStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// func clearStyle - reset the flow style of JSON to the default block style of YAML
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		clearStyle(n)
+	}
+}
+
+// func writeNDJSON - write rows of the report as JSON objects, one per line. Without a chosen report
+// rows of all reports are written, tagged with the report name in the "report" key.
+func writeNDJSON(w io.Writer, inv *inventory, name string) error {
+	return writeNDJSONReports(w, inv, name, nil)
+}
+
+// func writeNDJSONReports - write rows of the chosen report, or of all reports, except the skipped ones
+func writeNDJSONReports(w io.Writer, inv *inventory, name string, skip map[string]bool) error {
+	names := []string{name}
+	if name == "" {
+		names = reportNames()
+	}
+	for _, n := range names {
+		if skip[n] {
+			continue
+		}
+		if err := writeNDJSONRows(w, reportRows(inv, n), n, name == ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func writeNDJSONRows - write rows, a slice, one per line, tagged with the report name if tag is set
+func writeNDJSONRows(w io.Writer, rows reflect.Value, report string, tag bool) error {
+	for i := 0; i < rows.Len(); i++ {
+		line, err := json.Marshal(rows.Index(i).Interface())
+		if err != nil {
+			return err
+		}
+		if tag {
+			line = tagReport(line, report)
+		}
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sink of rows composed while the inventory is collected: the report name and a slice of its rows.
+// It is called concurrently by all engines.
+type rowSink func(report string, rows any) error
+
+// Reports passed to the rowSink page by page of VMs, see engine.inventory. Rows of the other reports
+// depend on all VMs, e.g. a disk lists VMs of all pages, they are known once the inventory is collected.
+var streamedReports = map[string]bool{"vms": true, "snapshots": true}
+
+// NDJSON output written while the inventory is collected.
+type ndjsonStream struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	report string // The chosen report, empty for all reports.
+}
+
+// func rows - write the rows and flush them, rows of a report other than the chosen one are dropped
+func (s *ndjsonStream) rows(report string, rows any) error {
+	if s.report != "" && s.report != report {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := writeNDJSONRows(s.w, reflect.ValueOf(rows), report, s.report == ""); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// func streamNDJSON - write the inventory in NDJSON format to the output file or stdout while collect runs
+//
+// collect passes rows of streamedReports to the sink as soon as a page of VMs is composed, they are written
+// at once. Rows of the other reports are written after collect returns. Rows of an engine failed after
+// some pages are already written stay in the output.
+func streamNDJSON(c outputConfig, collect func(rowSink) (*inventory, []string)) (*inventory, []string, error) {
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if c.File != "" && c.File != "-" {
+		var err error
+		if f, err = os.Create(c.File); err != nil {
+			return nil, nil, err
+		}
+		w = f
+	}
+	s := &ndjsonStream{w: bufio.NewWriter(w), report: c.Report}
+	inv, failed := collect(s.rows)
+	err := writeNDJSONReports(s.w, inv, c.Report, streamedReports)
+	if err == nil {
+		err = s.w.Flush()
+	}
+	if f != nil {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			err = fmt.Errorf("output file %s: %w", c.File, err)
+		}
+	}
+	return inv, failed, err
+}
+
+// func tagReport - add "report" key with the report name to the JSON object
+func tagReport(object []byte, name string) []byte {
+	tag, _ := json.Marshal(name)
+	tagged := append([]byte(`{"report":`), tag...)
+	body := bytes.TrimPrefix(object, []byte("{"))
+	if !bytes.HasPrefix(body, []byte("}")) {
+		tagged = append(tagged, ',')
+	}
+	return append(tagged, body...)
+}
+
+// Column of a CSV report.
+type csvColumn struct {
+	name  string // JSON name of the field.
+	index []int  // Index of the field for reflect.Value.FieldByIndex.
+}
+
+// func csvColumns - columns of rows of type t in the order of fields, embedded structs are flattened
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for _, c := range csvColumns(f.Type) {
+				columns = append(columns, csvColumn{name: c.name, index: append([]int{i}, c.index...)})
+			}
+			continue
+		}
+		if !f.IsExported() || f.Tag.Get("json") == "-" {
+			continue
+		}
+		columns = append(columns, csvColumn{name: jsonName(f), index: []int{i}})
+	}
+	return columns
+}
+
+// func selectColumns - columns of the report in the chosen order, all columns if none are chosen
+func selectColumns(report string, names []string) ([]csvColumn, error) {
+	t, ok := reportType(report)
+	if !ok {
+		return nil, fmt.Errorf("unknown report %q", report)
+	}
+	all := csvColumns(t)
+	if len(names) == 0 {
+		return all, nil
+	}
+	byName := make(map[string]csvColumn, len(all))
+	available := make([]string, 0, len(all))
+	for _, c := range all {
+		byName[c.name] = c
+		available = append(available, c.name)
+	}
+	columns := make([]csvColumn, 0, len(names))
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q of report %s, use one of %s", name, report, strings.Join(available, ", "))
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// func writeCSV - write rows of the report as CSV with a header row
+func writeCSV(w io.Writer, inv *inventory, report string, names []string) error {
+	columns, err := selectColumns(report, names)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	rows := reportRows(inv, report)
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		for j, c := range columns {
+			if record[j], err = csvValue(row.FieldByIndex(c.index)); err != nil {
+				return err
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// func csvValue - render a field as a CSV cell
+//
+// Lists of plain values are joined with ";", maps are rendered as sorted "key=value" pairs,
+// lists of objects are rendered as JSON.
+func csvValue(v reflect.Value) (string, error) {
+	if v.Type().Implements(stringerType) {
+		return v.Interface().(fmt.Stringer).String(), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			items := make([]string, v.Len())
+			for i := range items {
+				item, err := csvValue(v.Index(i))
+				if err != nil {
+					return "", err
+				}
+				items[i] = item
+			}
+			return strings.Join(items, csvListSeparator), nil
+		}
+	case reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, err := csvValue(iter.Value())
+			if err != nil {
+				return "", err
+			}
+			pairs = append(pairs, fmt.Sprint(iter.Key().Interface())+"="+value)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, csvListSeparator), nil
+	}
+	if v.Kind() == reflect.Slice && v.Len() == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(v.Interface())
+	return string(data), err
+}
+
+// func jsonName - name of the field in JSON
+func jsonName(f reflect.StructField) string {
+	if n, _, _ := strings.Cut(f.Tag.Get("json"), ","); n != "" {
+		return n
+	}
+	return f.Name
+}
+
+// Columns in bytes, shown as human-readable sizes in XLSX and HTML.
+var sizeColumns = map[string]bool{
+	"memory":           true,
+	"provisioned_size": true,
+	"actual_size":      true,
+	"total_size":       true,
+	"snapshots_size":   true,
+	"available":        true,
+	"used":             true,
+	"committed":        true,
+	"total":            true,
+	"host_memory":      true,
+	"running_memory":   true,
+}
+
+// Spelling of acronyms in column titles.
+var titleAcronyms = map[string]string{
+	"cpu": "CPU", "fqdn": "FQDN", "id": "ID", "ipv4": "IPv4", "ipv6": "IPv6", "mac": "MAC", "mtu": "MTU",
+	"os": "OS", "qos": "QoS", "uuid": "UUID", "vlan": "VLAN", "vms": "VMs",
+}
+
+// func columnTitle - title of the column, the JSON name in words: "provisioned_size" is "Provisioned size",
+// "vms_count" is "VMs count"
+func columnTitle(name string) string {
+	words := strings.Split(name, "_")
+	for i, w := range words {
+		if acronym, ok := titleAcronyms[w]; ok {
+			words[i] = acronym
+		}
+	}
+	title := strings.Join(words, " ")
+	return strings.ToUpper(title[:1]) + title[1:]
+}
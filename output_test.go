@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testInventory() *inventory {
+	return &inventory{
+		VMs: []vmStats{{
+			Engine:         "engine1",
+			Name:           "web01",
+			Cpu:            2,
+			CreationTime:   Timestamp{time.Date(2023, 2, 17, 9, 30, 0, 0, time.UTC)},
+			DiskSizeByTier: map[string]int{"ssd": 10, "hdd": 20},
+			IPv4:           []string{"10.0.0.1", "10.0.0.2"},
+		}},
+		Clusters: []clusterStats{{Name: "prod", rollup: rollup{VmsCount: 3}}},
+	}
+}
+
+func TestWriteInventory(t *testing.T) {
+	tests := []struct {
+		name   string
+		output outputConfig
+		want   string
+	}{
+		{
+			name:   "csv columns",
+			output: outputConfig{Format: formatCSV, Columns: []string{"name", "creation_time", "disk_size_by_tier", "ipv4", "cpu"}},
+			want: "name,creation_time,disk_size_by_tier,ipv4,cpu\n" +
+				"web01,2023-02-17T09:30:00Z,hdd=20;ssd=10,10.0.0.1;10.0.0.2,2\n",
+		},
+		{
+			name:   "csv embedded columns",
+			output: outputConfig{Format: formatCSV, Report: "clusters", Columns: []string{"name", "vms_count"}},
+			want:   "name,vms_count\nprod,3\n",
+		},
+		{
+			name:   "ndjson report",
+			output: outputConfig{Format: formatNDJSON, Report: "clusters"},
+			want:   `{"name":"prod","hosts_count":0,"host_cpu_threads":0,"host_memory":0,"vms_count":3,"vms_running":0,"cpu":0,"memory":0,"running_cpu":0,"running_memory":0}` + "\n",
+		},
+		{
+			name:   "yaml report",
+			output: outputConfig{Format: formatYAML, Report: "clusters"},
+			want: "- name: prod\n  hosts_count: 0\n  host_cpu_threads: 0\n  host_memory: 0\n  vms_count: 3\n" +
+				"  vms_running: 0\n  cpu: 0\n  memory: 0\n  running_cpu: 0\n  running_memory: 0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.output.validate(); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := writeInventory(&buf, tt.output, testInventory()); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("writeInventory() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteNDJSONTagsReports(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeInventory(&buf, outputConfig{Format: formatNDJSON}, testInventory()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+	for i, prefix := range []string{`{"report":"vms","engine":"engine1",`, `{"report":"clusters","name":"prod",`} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %s, want prefix %s", i, lines[i], prefix)
+		}
+	}
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		report string
+		want   []string // Prefixes of the lines in order.
+	}{
+		{
+			name: "all reports",
+			want: []string{`{"report":"vms","engine":"engine1",`, `{"report":"clusters","name":"prod",`},
+		},
+		{
+			name:   "streamed report",
+			report: "vms",
+			want:   []string{`{"engine":"engine1",`},
+		},
+		{
+			name:   "other report",
+			report: "clusters",
+			want:   []string{`{"name":"prod",`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "inventory.ndjson")
+			c := outputConfig{Format: formatNDJSON, File: path, Report: tt.report}
+			inv := testInventory()
+			_, _, err := streamNDJSON(c, func(sink rowSink) (*inventory, []string) {
+				if err := sink("vms", inv.VMs); err != nil {
+					t.Fatal(err)
+				}
+				// Rows of the page are in the file before the inventory is collected.
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if streamed := len(data) > 0; streamed != (tt.report == "" || tt.report == "vms") {
+					t.Errorf("VMs written during the collection = %v, want %v", streamed, !streamed)
+				}
+				return inv, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d: %q", len(lines), len(tt.want), data)
+			}
+			for i, prefix := range tt.want {
+				if !strings.HasPrefix(lines[i], prefix) {
+					t.Errorf("line %d = %s, want prefix %s", i, lines[i], prefix)
+				}
+			}
+		})
+	}
+}
+
+func TestOutputConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		output outputConfig
+	}{
+		{"unknown format", outputConfig{Format: "xml"}},
+		{"unknown report", outputConfig{Format: formatJSON, Report: "VMs"}},
+		{"unknown column", outputConfig{Format: formatCSV, Columns: []string{"nam"}}},
+		{"columns not in csv", outputConfig{Format: formatJSON, Columns: []string{"name"}}},
+		{"report in xlsx", outputConfig{Format: formatXLSX, Report: "vms"}},
+		{"url not in influx or graphite", outputConfig{Format: formatJSON, URL: "http://influx:8086/write"}},
+		{"tcp url in influx", outputConfig{Format: formatInflux, URL: "tcp://influx:8086"}},
+		{"http url in graphite", outputConfig{Format: formatGraphite, URL: "http://graphite:2003"}},
+		{"url and file", outputConfig{Format: formatInflux, URL: "http://influx:8086/write", File: "out.txt"}},
+		{"ca file with http url", outputConfig{Format: formatInflux, URL: "http://influx:8086/write", CAFile: "ca.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.output.validate(); err == nil {
+				t.Errorf("validate() of %+v succeeded, want error", tt.output)
+			}
+		})
+	}
+}
+
+func TestColumnTitle(t *testing.T) {
+	tests := map[string]string{
+		"provisioned_size": "Provisioned size",
+		"vms_count":        "VMs count",
+		"ipv4":             "IPv4",
+		"fqdn":             "FQDN",
+	}
+	for name, want := range tests {
+		if got := columnTitle(name); got != want {
+			t.Errorf("columnTitle(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
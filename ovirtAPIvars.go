@@ -1,10 +1,10 @@
 package main
 
-import "time"
-
 type Vm struct {
 	// Reference to virtual machine’s BIOS configuration.
 	Bios Bios `json:"bios,omitempty"`
+	// Reference to the cluster the virtual machine belongs to.
+	Cluster Cluster `json:"cluster,omitempty"`
 	// Free text containing comments about this object.
 	Comment string `json:"comment,omitempty"`
 	// Console configured for this virtual machine.
@@ -13,17 +13,17 @@ type Vm struct {
 	Cpu       Cpu `json:"cpu,omitempty"`
 	CpuShares int `json:"cpu_shares,omitempty"`
 	// The virtual machine creation date.
-	// When requesting the JSON representation the engine uses a different, format:
-	// an integer containing the number of seconds since Jan 1st 1970, also know as epoch time [https://en.wikipedia.org/wiki/Unix_time].
-	CreationTime               time.Duration    `json:"creation_time"`
+	CreationTime               Timestamp        `json:"creation_time"`
 	CustomCompatibilityVersion Version          `json:"custom_compatibility_version,omitempty"`
 	CustomCpuModel             string           `json:"custom_cpu_model,omitempty"`
 	CustomEmulatedMachine      string           `json:"custom_emulated_machine,omitempty"`
-	CustomProperty             []CustomProperty `json:"custom_property,omitempty"`
+	CustomProperties           []CustomProperty `json:"custom_properties,omitempty"`
 	// If true, the virtual machine cannot be deleted.
 	DeleteProtected bool `json:"delete_protected,omitempty"`
 	// A human-readable description in plain text.
 	Description string `json:"description,omitempty"`
+	// Disks attached to the virtual machine, filled if the link is followed, see vmFollowLinks.
+	DiskAttachments []DiskAttachment `json:"disk_attachments,omitempty"`
 	// The virtual machine display configuration.
 	Display Display `json:"display,omitempty"`
 	// Domain configured for this virtual machine.
@@ -38,10 +38,12 @@ type Vm struct {
 	HasIllegalImages bool `json:"has_illegal_images,omitempty"`
 	// The virtual machine high availability configuration.
 	HighAvailability HighAvailability `json:"high_availability,omitempty"`
-	ID               string           `json:"id,omitempty"`             // A unique identifier.
-	Initialization   Initialization   `json:"initialization,omitempty"` // Reference to the virtual machine’s initialization configuration.
-	IO               Io               `json:"io,omitempty"`             // For performance tuning of IO threading.
-	Large_icon       Icon             `json:"large___icon,omitempty"`   // Virtual machine’s large icon.
+	// Reference to the host the virtual machine is running on.
+	Host           Host           `json:"host,omitempty"`
+	ID             string         `json:"id,omitempty"`             // A unique identifier.
+	Initialization Initialization `json:"initialization,omitempty"` // Reference to the virtual machine’s initialization configuration.
+	IO             Io             `json:"io,omitempty"`             // For performance tuning of IO threading.
+	LargeIcon      Icon           `json:"large_icon,omitempty"`     // Virtual machine’s large icon.
 	// Reference to the storage domain this virtual machine/template lease reside on.
 	Lease                       StorageDomainLease            `json:"lease,omitempty"`
 	Memory                      int                           `json:"memory,omitempty"`                         // The virtual machine’s memory, in bytes.
@@ -51,11 +53,14 @@ type Vm struct {
 	MultiQueuesEnabled          bool                          `json:"multi_queues_enabled,omitempty"`           // If true, each virtual interface will get the optimal number of queues, depending on the available virtual Cpus.
 	Name                        string                        `json:"name,omitempty"`                           // A human-readable name in plain text.
 	NextRunConfigurationExists  bool                          `json:"next_run_configuration_exists,omitempty"`  // Virtual machine configuration has been changed and requires restart of the virtual machine.
+	Nics                        []Nic                         `json:"nics,omitempty"`                           // Network interfaces of the virtual machine, filled if the link is followed.
 	NumaTuneMode                NumaTuneMode                  `json:"numa_tune_mode,omitempty"`                 // How the NUMA topology is applied.
 	Origin                      string                        `json:"origin,omitempty"`                         // The origin of this virtual machine.
+	OriginalTemplate            *Template                     `json:"original_template,omitempty"`              // Reference to the template the virtual machine was originally created from.
 	OS                          OperatingSystem               `json:"os,omitempty"`                             // Operating system type installed on the virtual machine.
 	Payloads                    []Payload                     `json:"payloads,omitempty"`                       // Optional payloads of the virtual machine, used for ISOs to configure it.
 	PlacementPolicy             VmPlacementPolicy             `json:"placement_policy,omitempty"`               // The configuration of the virtual machine’s placement policy.
+	ReportedDevices             []ReportedDevice              `json:"reported_devices,omitempty"`               // Devices reported by the guest agent, filled if the link is followed.
 	RngDevice                   RngDevice                     `json:"rng_device,omitempty"`                     // Random Number Generator device configuration for this virtual machine.
 	RunOnce                     bool                          `json:"run_once,omitempty"`                       // If true, the virtual machine has been started using the run once command, meaning it’s configuration might differ from the stored one for the purpose of this single run.
 	SerialNumber                SerialNumber                  `json:"serial_number,omitempty"`                  // Virtual machine’s serial number in a cluster.
@@ -63,29 +68,30 @@ type Vm struct {
 	SoundcardEnabled            bool                          `json:"soundcard_enabled,omitempty"`              // If true, the sound card is added to the virtual machine.
 	SSO                         Sso                           `json:"sso,omitempty"`                            // Reference to the Single Sign On configuration this virtual machine is configured for.
 	StartPaused                 bool                          `json:"start_paused,omitempty"`                   // If true, the virtual machine will be initially in 'paused' state after start.
-	StartTime                   time.Duration                 `json:"start_time,omitempty"`                     // The date in which the virtual machine was started.
+	StartTime                   Timestamp                     `json:"start_time,omitempty"`                     // The date in which the virtual machine was started.
 	Stateless                   bool                          `json:"stateless,omitempty"`                      // If true, the virtual machine is stateless - it’s state (disks) are rolled-back after shutdown.
 	Status                      VmStatus                      `json:"status,omitempty"`                         // The current status of the virtual machine.
 	StatusDetail                string                        `json:"status_detail,omitempty"`                  // Human readable detail of current status.
 	StopReason                  string                        `json:"stop_reason,omitempty"`                    // The reason the virtual machine was stopped.
-	StopTime                    time.Duration                 `json:"stop_time,omitempty"`                      // The date in which the virtual machine was stopped.
+	StopTime                    Timestamp                     `json:"stop_time,omitempty"`                      // The date in which the virtual machine was stopped.
 	StorageErrorResumeBehaviour VmStorageErrorResumeBehaviour `json:"storage_error_resume_behaviour,omitempty"` // Determines how the virtual machine will be resumed after storage error.
+	Template                    *Template                     `json:"template,omitempty"`                       // Reference to the template the virtual machine is based on, Blank template if none.
 	TimeZone                    TimeZone                      `json:"time_zone,omitempty"`                      // The virtual machine’s time zone set by oVirt.
 	TunnelMigration             bool                          `json:"tunnel_migration,omitempty"`               // If true, the network data transfer will be encrypted during virtual machine live migration.
 	Type                        VmType                        `json:"type,omitempty"`                           // Determines whether the virtual machine is optimized for desktop or server.
 	USB                         Usb                           `json:"usb,omitempty"`                            // Configuration of USB devices for this virtual machine (count, type).
 	UseLatestTemplateVersion    bool                          `json:"use_latest_template_version,omitempty"`    // If true, the virtual machine is reconfigured to the latest version of it’s template when it is started.
-	VirtioScsi                  VirtioScsi                    // Reference to VirtIO SCSI configuration.
+	VirtioScsi                  VirtioScsi                    `json:"virtio_scsi,omitempty"`                    // Reference to VirtIO SCSI configuration.
 }
 
 type Bios struct {
-	BootMenu BootMenu
-	Type     BiosType // Chipset and BIOS type combination.
+	BootMenu BootMenu `json:"boot_menu,omitempty"`
+	Type     BiosType `json:"type,omitempty"` // Chipset and BIOS type combination.
 }
 
 // Represents boot menu configuration for virtual machines and templates.
 type BootMenu struct {
-	Enabled bool //Whether the boot menu is enabled for this virtual machine (or template), or not.
+	Enabled bool `json:"enabled,omitempty"` //Whether the boot menu is enabled for this virtual machine (or template), or not.
 }
 
 // BiosType enum
@@ -99,7 +105,7 @@ type BiosType string
 
 // Representation for serial console device.
 type Console struct {
-	Enabled bool
+	Enabled bool `json:"enabled,omitempty"`
 }
 
 type Cpu struct {
@@ -337,13 +343,13 @@ type Initialization struct {
 //
 // This type has been deprecated and replaced by alternative attributes inside the Initialization type. See the cloud_init attribute documentation for details.
 type CloudInit struct {
-	AuthorizedKeys       []AuthorizedKey
-	Files                []File
-	Host                 Host
-	NetworkConfiguration NetworkConfiguration
-	RegenerateSshKeys    bool
-	Timezone             string
-	Users                []User
+	AuthorizedKeys       []AuthorizedKey      `json:"authorized_keys,omitempty"`
+	Files                []File               `json:"files,omitempty"`
+	Host                 Host                 `json:"host,omitempty"`
+	NetworkConfiguration NetworkConfiguration `json:"network_configuration,omitempty"`
+	RegenerateSshKeys    bool                 `json:"regenerate_ssh_keys,omitempty"`
+	Timezone             string               `json:"timezone,omitempty"`
+	Users                []User               `json:"users,omitempty"`
 }
 
 type AuthorizedKey struct {
@@ -374,81 +380,85 @@ type File struct {
 // Type representing a host.
 type Host struct {
 	// The host address (FQDN/IP).
-	Address string
+	Address string `json:"address,omitempty"`
 	// The host auto non uniform memory access (NUMA) status.
-	AutoNumaStatus AutoNumaStatus
+	AutoNumaStatus AutoNumaStatus `json:"auto_numa_status,omitempty"`
 	// The host certificate.
-	Certificate Certificate
+	Certificate Certificate `json:"certificate,omitempty"`
+	// Reference to the cluster the host belongs to.
+	Cluster Cluster `json:"cluster,omitempty"`
 	// Free text containing comments about this object.
-	Comment string
+	Comment string `json:"comment,omitempty"`
 	// The CPU type of this host.
-	CPU Cpu
+	CPU Cpu `json:"cpu,omitempty"`
 	// A human-readable description in plain text.
-	Description string
+	Description string `json:"description,omitempty"`
 	// Specifies whether host device passthrough is enabled on this host.
-	DevicePassthrough HostDevicePassthrough
+	DevicePassthrough HostDevicePassthrough `json:"device_passthrough,omitempty"`
 	// Optionally specify the display address of this host explicitly.
-	Display Display
+	Display Display `json:"display,omitempty"`
 	// The host external status.
-	ExternalStatus ExternalStatus
+	ExternalStatus ExternalStatus `json:"external_status,omitempty"`
 	// The host hardware information.
-	HardwareInformation HardwareInformation
+	HardwareInformation HardwareInformation `json:"hardware_information,omitempty"`
 	// The self-hosted engine status of this host.
-	HostedEngine HostedEngine
+	HostedEngine HostedEngine `json:"hosted_engine,omitempty"`
 	// A unique identifier.
-	ID string
+	ID string `json:"id,omitempty"`
 	// The host iSCSI details.
-	ISCSI IscsiDetails
+	ISCSI IscsiDetails `json:"iscsi,omitempty"`
 	// The host KDUMP status.
-	KdumpStatus KdumpStatus
+	KdumpStatus KdumpStatus `json:"kdump_status,omitempty"`
 	// Kernel SamePage Merging (KSM) reduces references to memory pages from multiple identical pages to a single page reference.
-	KSM Ksm
+	KSM Ksm `json:"ksm,omitempty"`
 	// The host libvirt version.
-	LibvirtVersion Version
+	LibvirtVersion Version `json:"libvirt_version,omitempty"`
 	// The max scheduling memory on this host in bytes.
-	MaxSchedulingMemory int
+	MaxSchedulingMemory int `json:"max_scheduling_memory,omitempty"`
 	// The amount of physical memory on this host in bytes.
-	Memory int
+	Memory int `json:"memory,omitempty"`
 	// A human-readable name in plain text.
-	Name string
+	Name string `json:"name,omitempty"`
+	// Networks attached to the host, filled if the link is followed.
+	NetworkAttachments []NetworkAttachment `json:"network_attachments,omitempty"`
 	// Specifies whether a network-related operation, such as 'setup networks', 'sync networks', or 'refresh capabilities', is currently being executed on this host.
-	NetworkOperationInProgress bool
+	NetworkOperationInProgress bool `json:"network_operation_in_progress,omitempty"`
 	// Specifies whether non uniform memory access (NUMA) is supported on this host.
-	NumaSupported bool
+	NumaSupported bool `json:"numa_supported,omitempty"`
 	// The operating system on this host.
-	OS OperatingSystem
+	OS OperatingSystem `json:"os,omitempty"`
 	// Specifies whether we should override firewall definitions.
-	OverrideIptables bool
+	OverrideIptables bool `json:"override_iptables,omitempty"`
 	// The host port.
-	Port int
+	Port int `json:"port,omitempty"`
 	// The host power management definitions.
-	PowerManagement PowerManagement
+	PowerManagement PowerManagement `json:"power_management,omitempty"`
 	// The protocol that the engine uses to communicate with the host.
-	Protocol HostProtocol
+	Protocol HostProtocol `json:"protocol,omitempty"`
 	// When creating a new host, a root password is required if the password authentication method is chosen, but this is not subsequently included in the representation.
-	RootPassword string
+	RootPassword string `json:"root_password,omitempty"`
 	// The host SElinux status.
-	SeLinux SeLinux
+	SeLinux SeLinux `json:"se_linux,omitempty"`
 	// The host storage pool manager (SPM) status and definition.
-	SPM Spm
+	SPM Spm `json:"spm,omitempty"`
 	// The SSH definitions.
-	Ssh Ssh
+	Ssh Ssh `json:"ssh,omitempty"`
 	// The host status.
-	Status HostStatus
+	Status HostStatus `json:"status,omitempty"`
 	// The host status details.
-	StatusDetail string
+	StatusDetail string `json:"status_detail,omitempty"`
 	// The virtual machine summary - how many are active, migrating and total.
-	Summary VmSummary
+	Summary VmSummary `json:"summary,omitempty"`
 	// Transparent huge page support expands the size of memory pages beyond the standard 4 KiB limit.
-	TransparentHugePages TransparentHugePages
+	TransparentHugePages TransparentHugePages `json:"transparent_huge_pages,omitempty"`
 	// Indicates if the host contains a full installation of the operating system or a scaled-down version intended only to host virtual machines.
-	Type HostType
+	Type HostType `json:"type,omitempty"`
 	// Specifies whether there is an oVirt-related update on this host.
-	UpdateAvailable bool
+	UpdateAvailable bool `json:"update_available,omitempty"`
 	// The version of VDSM.
-	Version Version
+	Version Version `json:"version,omitempty"`
 	// Specifies the vGPU placement strategy.
-	VgpuPlacement VgpuPlacement
+	VgpuPlacement VgpuPlacement `json:"vgpu_placement,omitempty"`
 }
 
 // AutoNumaStatus enum of:
@@ -458,7 +468,7 @@ type Host struct {
 type AutoNumaStatus string
 
 type HostDevicePassthrough struct {
-	Enabled bool
+	Enabled bool `json:"enabled,omitempty"`
 }
 
 // ExternalStatus enum
@@ -728,8 +738,8 @@ type HostType string
 type VgpuPlacement string
 
 type NetworkConfiguration struct {
-	DNS  Dns
-	Nics []Nic
+	DNS  Dns   `json:"dns,omitempty"`
+	Nics []Nic `json:"nics,omitempty"`
 }
 
 // Represents the DNS resolver configuration.
@@ -740,16 +750,18 @@ type Dns struct {
 
 // Represents a virtual machine NIC.
 type Nic struct {
-	BootProtocol BootProtocol `json:"boot_protocol,omitempty"` //	Defines how an IP address is assigned to the NIC.
-	Comment      string       `json:"comment,omitempty"`       //	Free text containing comments about this object.
-	Description  string       `json:"description,omitempty"`   //	A human-readable description in plain text.
-	ID           string       `json:"id,omitempty"`            //	A unique identifier.
-	Interface    NicInterface `json:"interface,omitempty"`     //	The type of driver used for the NIC.
-	Linked       bool         `json:"linked,omitempty"`        //	Defines if the NIC is linked to the virtual machine.
-	MAC          Mac          `json:"mac,omitempty"`           //	The MAC address of the interface.
-	Name         string       `json:"name,omitempty"`          //	A human-readable name in plain text.
-	OnBoot       bool         `json:"on_boot,omitempty"`       //	Defines if the network interface should be activated upon operation system startup.
-	Plugged      bool         `json:"plugged,omitempty"`       //	Defines if the NIC is plugged in to the virtual machine.
+	BootProtocol    BootProtocol     `json:"boot_protocol,omitempty"`    //	Defines how an IP address is assigned to the NIC.
+	Comment         string           `json:"comment,omitempty"`          //	Free text containing comments about this object.
+	Description     string           `json:"description,omitempty"`      //	A human-readable description in plain text.
+	ID              string           `json:"id,omitempty"`               //	A unique identifier.
+	Interface       NicInterface     `json:"interface,omitempty"`        //	The type of driver used for the NIC.
+	Linked          bool             `json:"linked,omitempty"`           //	Defines if the NIC is linked to the virtual machine.
+	MAC             Mac              `json:"mac,omitempty"`              //	The MAC address of the interface.
+	Name            string           `json:"name,omitempty"`             //	A human-readable name in plain text.
+	OnBoot          bool             `json:"on_boot,omitempty"`          //	Defines if the network interface should be activated upon operation system startup.
+	Plugged         bool             `json:"plugged,omitempty"`          //	Defines if the NIC is plugged in to the virtual machine.
+	ReportedDevices []ReportedDevice `json:"reported_devices,omitempty"` //	Devices reported by the guest agent for the NIC, filled if the link is followed.
+	VnicProfile     VnicProfile      `json:"vnic_profile,omitempty"`     //	Reference to the vNIC profile of the NIC.
 }
 
 // BootProtocol enum
@@ -773,9 +785,24 @@ type BootProtocol string
 //   - virtio	 - 	VirtIO.
 type NicInterface string
 
+// Represents a device reported by the guest agent.
+type ReportedDevice struct {
+	Comment     string             `json:"comment,omitempty"`     //	Free text containing comments about this object.
+	Description string             `json:"description,omitempty"` //	A human-readable description in plain text.
+	ID          string             `json:"id,omitempty"`          //	A unique identifier.
+	Ips         []Ip               `json:"ips,omitempty"`         //	A list of IP configurations of the device.
+	Mac         Mac                `json:"mac,omitempty"`         //	MAC configuration of the device.
+	Name        string             `json:"name,omitempty"`        //	A human-readable name in plain text.
+	Type        ReportedDeviceType `json:"type,omitempty"`        //	Type of the device.
+}
+
+// ReportedDeviceType enum
+//   - network
+type ReportedDeviceType string
+
 // Represents a MAC address of a virtual network interface.
 type Mac struct {
-	Address string // MAC address.
+	Address string `json:"address,omitempty"` // MAC address.
 }
 
 // CloudInitNetworkProtocol enum
@@ -857,7 +884,7 @@ type Icon struct {
 // resource residing on a special volume on the storage domain,
 // this Sanlock resource is used to provide storage base locking.
 type StorageDomainLease struct {
-	StorageDomain StorageDomain // Reference to the storage domain on which the lock resides on.
+	StorageDomain StorageDomain `json:"storage_domain,omitempty"` // Reference to the storage domain on which the lock resides on.
 }
 
 // Storage domain.
@@ -908,7 +935,7 @@ type HostStorage struct {
 	ID                     string        `json:"id,omitempty"`                       //	A unique identifier.
 	LogicalUnits           []LogicalUnit `json:"logical_units,omitempty"`
 	MountOptions           string        `json:"mount_options,omitempty"`
-	Mame                   string        `json:"mame,omitempty"`        //	A human-readable name in plain text.
+	Name                   string        `json:"name,omitempty"`        //	A human-readable name in plain text.
 	NfsRetrans             int           `json:"nfs_retrans,omitempty"` //	The number of times to retry a request before attempting further recovery actions.
 	NfsTimeo               int           `json:"nfs_timeo,omitempty"`   //	The time in tenths of a second to wait for a response before retrying NFS requests.
 	NfsVersion             NfsVersion    `json:"nfs_version,omitempty"`
@@ -981,9 +1008,9 @@ type NfsVersion string
 type StorageType string
 
 type VolumeGroup struct {
-	ID           string
-	LogicalUnits []LogicalUnit
-	Name         string
+	ID           string        `json:"id,omitempty"`
+	LogicalUnits []LogicalUnit `json:"logical_units,omitempty"`
+	Name         string        `json:"name,omitempty"`
 }
 
 // StorageFormat enum
@@ -1039,8 +1066,8 @@ type Inheritablebool string
 
 // Defines the bandwidth used by migration.
 type MigrationBandwidth struct {
-	AssignmentMethod MigrationBandwidthAssignmentMethod //	The method used to assign the bandwidth.
-	CustomValue      int                                //	Custom bandwidth in Mbps. Will be applied only if the assignmentMethod attribute is custom.
+	AssignmentMethod MigrationBandwidthAssignmentMethod `json:"assignment_method,omitempty"` //	The method used to assign the bandwidth.
+	CustomValue      int                                `json:"custom_value,omitempty"`      //	Custom bandwidth in Mbps. Will be applied only if the assignmentMethod attribute is custom.
 }
 
 // MigrationBandwidthAssignmentMethod enum
@@ -1069,9 +1096,9 @@ type MigrationPolicy struct {
 type NumaTuneMode string
 
 type Payload struct {
-	Files    []File
-	Type     VmDeviceType
-	VolumeID string
+	Files    []File       `json:"files,omitempty"`
+	Type     VmDeviceType `json:"type,omitempty"`
+	VolumeID string       `json:"volume_id,omitempty"`
 }
 
 // VmDeviceType enum
@@ -1117,11 +1144,11 @@ type SerialNumber struct {
 type SerialNumberPolicy string
 
 type Sso struct {
-	Method []Method
+	Method []Method `json:"methods,omitempty"`
 }
 
 type Method struct {
-	ID SsoMethod
+	ID SsoMethod `json:"id,omitempty"`
 }
 
 // SsoMethod enum
@@ -1198,34 +1225,35 @@ type VirtioScsi struct {
 
 // Represents a virtual disk device.
 type Disk struct {
-	Active                bool //	Indicates if the disk is visible to the virtual machine.
-	ActualSize            int  //	The actual size of the disk, in bytes.
-	Alias                 string
-	Backup                DiskBackup      //	The backup behavior supported by the disk.
-	Bootable              bool            //	Indicates if the disk is marked as bootable.
-	Comment               string          //	Free text containing comments about this object.
-	ContentType           DiskContentType //	Indicates the actual content residing on the disk.
-	Description           string          //	A human-readable description in plain text.
-	Format                DiskFormat      //	The underlying storage format.
-	ID                    string          //	A unique identifier.
-	ImageID               string
-	InitialSize           int           //	The initial size of a sparse image disk created on block storage, in bytes.
-	Interface             DiskInterface //	The type of interface driver used to connect the disk device to the virtual machine.
-	LogicalName           string
-	LunStorage            HostStorage
-	Name                  string        //	A human-readable name in plain text.
-	PropagateErrors       bool          //	Indicates if disk errors should cause virtual machine to be paused or if disk errors should be propagated to the the guest operating system instead.
-	ProvisionedSize       int           //	The virtual size of the disk, in bytes.
-	QcowVersion           QcowVersion   //	The underlying QCOW version of a QCOW volume.
-	ReadOnly              bool          //	Indicates if the disk is in read-only mode.
-	Sgio                  ScsiGenericIO //	Indicates whether SCSI passthrough is enable and its policy.
-	Shareable             bool          //	Indicates if the disk can be attached to multiple virtual machines.
-	Sparse                bool          //	Indicates if the physical storage for the disk should not be preallocated.
-	Status                DiskStatus    //	The status of the disk device.
-	StorageType           DiskStorageType
-	TotalSize             int //	The total size of the disk including all of its snapshots, in bytes.
-	Uses_scsi_reservation bool
-	WipeAfterDelete       bool //	"Indicates if the disk’s blocks will be read back as zeros after it is deleted: - On block storage, the disk will be zeroed and only then deleted."
+	Active              bool            `json:"active,omitempty"`      //	Indicates if the disk is visible to the virtual machine.
+	ActualSize          int             `json:"actual_size,omitempty"` //	The actual size of the disk, in bytes.
+	Alias               string          `json:"alias,omitempty"`
+	Backup              DiskBackup      `json:"backup,omitempty"`       //	The backup behavior supported by the disk.
+	Bootable            bool            `json:"bootable,omitempty"`     //	Indicates if the disk is marked as bootable.
+	Comment             string          `json:"comment,omitempty"`      //	Free text containing comments about this object.
+	ContentType         DiskContentType `json:"content_type,omitempty"` //	Indicates the actual content residing on the disk.
+	Description         string          `json:"description,omitempty"`  //	A human-readable description in plain text.
+	Format              DiskFormat      `json:"format,omitempty"`       //	The underlying storage format.
+	ID                  string          `json:"id,omitempty"`           //	A unique identifier.
+	ImageID             string          `json:"image_id,omitempty"`
+	InitialSize         int             `json:"initial_size,omitempty"` //	The initial size of a sparse image disk created on block storage, in bytes.
+	Interface           DiskInterface   `json:"interface,omitempty"`    //	The type of interface driver used to connect the disk device to the virtual machine.
+	LogicalName         string          `json:"logical_name,omitempty"`
+	LunStorage          HostStorage     `json:"lun_storage,omitempty"`
+	Name                string          `json:"name,omitempty"`             //	A human-readable name in plain text.
+	PropagateErrors     bool            `json:"propagate_errors,omitempty"` //	Indicates if disk errors should cause virtual machine to be paused or if disk errors should be propagated to the the guest operating system instead.
+	ProvisionedSize     int             `json:"provisioned_size,omitempty"` //	The virtual size of the disk, in bytes.
+	QcowVersion         QcowVersion     `json:"qcow_version,omitempty"`     //	The underlying QCOW version of a QCOW volume.
+	ReadOnly            bool            `json:"read_only,omitempty"`        //	Indicates if the disk is in read-only mode.
+	Sgio                ScsiGenericIO   `json:"sgio,omitempty"`             //	Indicates whether SCSI passthrough is enable and its policy.
+	Shareable           bool            `json:"shareable,omitempty"`        //	Indicates if the disk can be attached to multiple virtual machines.
+	Sparse              bool            `json:"sparse,omitempty"`           //	Indicates if the physical storage for the disk should not be preallocated.
+	Status              DiskStatus      `json:"status,omitempty"`           //	The status of the disk device.
+	StorageDomains      []StorageDomain `json:"storage_domains,omitempty"`  //	The storage domains the disk resides on. Only IDs are sent, unless the link is followed.
+	StorageType         DiskStorageType `json:"storage_type,omitempty"`
+	TotalSize           int             `json:"total_size,omitempty"` //	The total size of the disk including all of its snapshots, in bytes.
+	UsesScsiReservation bool            `json:"uses_scsi_reservation,omitempty"`
+	WipeAfterDelete     bool            `json:"wipe_after_delete,omitempty"` //	"Indicates if the disk’s blocks will be read back as zeros after it is deleted: - On block storage, the disk will be zeroed and only then deleted."
 }
 
 // DiskBackup enum
@@ -1315,7 +1343,9 @@ type DiskAttachment struct {
 	Bootable    bool   `json:"bootable,omitempty"`    //	Defines whether the disk is bootable.
 	Comment     string `json:"comment,omitempty"`     //	Free text containing comments about this object.
 	Description string `json:"description,omitempty"` //	A human-readable description in plain text.
-	ID          string `json:"id,omitempty"`          //	A unique identifier.
+	//	The disk the attachment points to. Only the disk ID is sent, unless the link is followed.
+	Disk Disk   `json:"disk,omitempty"`
+	ID   string `json:"id,omitempty"` //	A unique identifier.
 	//	The type of interface driver used to connect the disk device to the virtual machine.
 	Interface DiskInterface `json:"interface,omitempty"`
 	//	The logical name of the virtual machine’s disk, as seen from inside the virtual machine.
@@ -1342,17 +1372,19 @@ type DiskAttachment struct {
 // virtual machines with common configuration and disk states.
 type Template struct {
 	Bios                        Bios                          `json:"bios,omitempty"`                         //	Reference to virtual machine’s BIOS configuration.
+	Cluster                     Cluster                       `json:"cluster,omitempty"`                      //	Reference to the cluster the template belongs to.
 	Comment                     string                        `json:"comment,omitempty"`                      //	Free text containing comments about this object.
 	Console                     Console                       `json:"console,omitempty"`                      //	Console configured for this virtual machine.
 	Cpu                         Cpu                           `json:"cpu,omitempty"`                          //	The configuration of the virtual machine CPU.
 	CpuShares                   int                           `json:"cpu_shares,omitempty"`                   //
-	CreationTime                time.Duration                 `json:"creation_time,omitempty"`                //	The virtual machine creation date.
+	CreationTime                Timestamp                     `json:"creation_time,omitempty"`                //	The virtual machine creation date.
 	CustomCompatibilityVersion  Version                       `json:"custom_compatibility_version,omitempty"` //	Virtual machine custom compatibility version.
 	CustomCpuModel              string                        `json:"custom_cpu_model,omitempty"`
 	CustomEmulatedMachine       string                        `json:"custom_emulated_machine,omitempty"`
 	CustomProperties            []CustomProperty              `json:"custom_properties,omitempty"`              //	Properties sent to VDSM to configure various hooks.
 	DeleteProtected             bool                          `json:"delete_protected,omitempty"`               //	If true, the virtual machine cannot be deleted.
 	Description                 string                        `json:"description,omitempty"`                    //	A human-readable description in plain text.
+	DiskAttachments             []DiskAttachment              `json:"disk_attachments,omitempty"`               //	Disk attachments of the template, filled if the link is followed.
 	Display                     Display                       `json:"display,omitempty"`                        //	The virtual machine display configuration.
 	Domain                      Domain                        `json:"domain,omitempty"`                         //	Domain configured for this virtual machine.
 	HighAvailability            HighAvailability              `json:"high_availability,omitempty"`              //	The virtual machine high availability configuration.
@@ -1398,6 +1430,255 @@ type TemplateStatus string
 
 // Type representing a version of a virtual machine template.
 type TemplateVersion struct {
-	VersionName   string `json:"version_name,omitempty"`   //	The name of this version.
-	VersionNumber int    `json:"version_number,omitempty"` //	The index of this version in the versions hierarchy of the template.
+	BaseTemplate  *Template `json:"base_template,omitempty"`  //	References the template that this version is associated with.
+	VersionName   string    `json:"version_name,omitempty"`   //	The name of this version.
+	VersionNumber int       `json:"version_number,omitempty"` //	The index of this version in the versions hierarchy of the template.
+}
+
+// Type representation of a cluster.
+type Cluster struct {
+	BallooningEnabled bool       `json:"ballooning_enabled,omitempty"` // Memory balloon is enabled for virtual machines of the cluster.
+	Comment           string     `json:"comment,omitempty"`            // Free text containing comments about this object.
+	CPU               Cpu        `json:"cpu,omitempty"`                // The CPU architecture and type of the cluster.
+	DataCenter        DataCenter `json:"data_center,omitempty"`        // Reference to the data center the cluster belongs to.
+	Description       string     `json:"description,omitempty"`        // A human-readable description in plain text.
+	GlusterService    bool       `json:"gluster_service,omitempty"`    // The cluster provides gluster storage service.
+	HaReservation     bool       `json:"ha_reservation,omitempty"`     // Resources of the cluster are reserved for highly available virtual machines.
+	ID                string     `json:"id,omitempty"`                 // A unique identifier.
+	Name              string     `json:"name,omitempty"`               // A human-readable name in plain text.
+	TrustedService    bool       `json:"trusted_service,omitempty"`    // The cluster runs virtual machines on trusted hosts only.
+	Version           Version    `json:"version,omitempty"`            // The compatibility version of the cluster.
+	VirtService       bool       `json:"virt_service,omitempty"`       // The cluster provides virtualization service.
+}
+
+// Type representation of a data center.
+type DataCenter struct {
+	Comment       string           `json:"comment,omitempty"`        // Free text containing comments about this object.
+	Description   string           `json:"description,omitempty"`    // A human-readable description in plain text.
+	ID            string           `json:"id,omitempty"`             // A unique identifier.
+	Local         bool             `json:"local,omitempty"`          // The data center uses local storage of its host.
+	Name          string           `json:"name,omitempty"`           // A human-readable name in plain text.
+	QuotaMode     QuotaModeType    `json:"quota_mode,omitempty"`     // Quota enforcement mode of the data center.
+	Status        DataCenterStatus `json:"status,omitempty"`         // The status of the data center.
+	StorageFormat StorageFormat    `json:"storage_format,omitempty"` // Format of storage domains of the data center.
+	Version       Version          `json:"version,omitempty"`        // The compatibility version of the data center.
+}
+
+// QuotaModeType enum
+//   - audit    - Quota violations are logged, actions are not blocked.
+//   - disabled - Quota is not used.
+//   - enabled  - Actions violating quota are blocked.
+type QuotaModeType string
+
+// DataCenterStatus enum
+//   - contend         - The data center is contending for the storage pool manager.
+//   - maintenance     - The data center is in maintenance.
+//   - not_operational - The data center is not operational.
+//   - problematic     - The data center has a problem.
+//   - uninitialized   - The data center has no master storage domain.
+//   - up              - The data center is up.
+type DataCenterStatus string
+
+// Type representing a snapshot of a virtual machine.
+type Snapshot struct {
+	Date               Timestamp      `json:"date,omitempty"`                // The date when this snapshot has been created.
+	Description        string         `json:"description,omitempty"`         // A human-readable description in plain text.
+	Disks              []Disk         `json:"disks,omitempty"`               // Disk images of the snapshot, filled if the link is followed.
+	ID                 string         `json:"id,omitempty"`                  // A unique identifier.
+	Name               string         `json:"name,omitempty"`                // A human-readable name in plain text.
+	PersistMemorystate bool           `json:"persist_memorystate,omitempty"` // Indicates if the content of the memory of the virtual machine is included in the snapshot.
+	SnapshotStatus     SnapshotStatus `json:"snapshot_status,omitempty"`     // Status of the snapshot.
+	SnapshotType       SnapshotType   `json:"snapshot_type,omitempty"`       // Type of the snapshot.
+	Vm                 *Vm            `json:"vm,omitempty"`                  // Reference to the virtual machine the snapshot belongs to.
+}
+
+// SnapshotStatus enum
+//   - in_preview - The snapshot is being previewed.
+//   - locked     - The snapshot is locked.
+//   - ok         - The snapshot is OK.
+type SnapshotStatus string
+
+// SnapshotType enum
+//   - active    - The active snapshot is the current state of the virtual machine.
+//   - preview   - The snapshot created to preview another snapshot.
+//   - regular   - A regular snapshot created by the user.
+//   - stateless - The snapshot created for a stateless virtual machine.
+type SnapshotType string
+
+// A vNIC profile is a collection of settings that can be applied to individual virtual machine NICs.
+type VnicProfile struct {
+	Comment       string  `json:"comment,omitempty"`        // Free text containing comments about this object.
+	Description   string  `json:"description,omitempty"`    // A human-readable description in plain text.
+	ID            string  `json:"id,omitempty"`             // A unique identifier.
+	Name          string  `json:"name,omitempty"`           // A human-readable name in plain text.
+	Network       Network `json:"network,omitempty"`        // Reference to the network the vNIC profile applies to.
+	PortMirroring bool    `json:"port_mirroring,omitempty"` // Enables port mirroring.
+	Qos           Qos     `json:"qos,omitempty"`            // Reference to the network QoS of the vNIC profile.
+}
+
+// The type for a logical network.
+type Network struct {
+	Comment     string         `json:"comment,omitempty"`     // Free text containing comments about this object.
+	DataCenter  DataCenter     `json:"data_center,omitempty"` // Reference to the data center the network belongs to.
+	Description string         `json:"description,omitempty"` // A human-readable description in plain text.
+	ID          string         `json:"id,omitempty"`          // A unique identifier.
+	Mtu         int            `json:"mtu,omitempty"`         // The maximum transmission unit of the network, 0 is the default of the engine, 1500.
+	Name        string         `json:"name,omitempty"`        // A human-readable name in plain text.
+	Qos         Qos            `json:"qos,omitempty"`         // Reference to the host network QoS of the network.
+	Required    bool           `json:"required,omitempty"`    // Hosts of clusters the network is attached to are not operational without the network.
+	Stp         bool           `json:"stp,omitempty"`         // Spanning tree protocol is enabled on the network.
+	Usages      []NetworkUsage `json:"usages,omitempty"`      // Usages of the network.
+	Vlan        Vlan           `json:"vlan,omitempty"`        // VLAN tag of the network, empty for an untagged network.
+}
+
+// NetworkUsage enum
+//
+// This type indicates the purpose that the network is used for in the cluster.
+//   - default_route - The default gateway and the DNS resolver configuration of the host are taken from this network.
+//   - display       - The network is used for SPICE and VNC traffic.
+//   - gluster       - The network is used for gluster (bricks) data traffic.
+//   - management    - The network is used for communication between the engine and the hosts.
+//   - migration     - The network is used for virtual machine migration.
+//   - vm            - The network is used for virtual machine traffic.
+type NetworkUsage string
+
+// Type representing a VLAN tag.
+type Vlan struct {
+	ID int `json:"id,omitempty"` // The VLAN ID.
+}
+
+// Describes how a host connects to a network.
+type NetworkAttachment struct {
+	HostNic HostNic `json:"host_nic,omitempty"` // Reference to the host NIC the network is attached to.
+	ID      string  `json:"id,omitempty"`       // A unique identifier.
+	Network Network `json:"network,omitempty"`  // Reference to the network attached to the host.
+}
+
+// Represents a host NIC.
+type HostNic struct {
+	ID   string `json:"id,omitempty"`   // A unique identifier.
+	Name string `json:"name,omitempty"` // A human-readable name in plain text.
+}
+
+// This type represents the attributes to define Quality of service (QoS).
+type Qos struct {
+	DataCenter      DataCenter `json:"data_center,omitempty"`      // The data center the QoS is assigned to.
+	Description     string     `json:"description,omitempty"`      // A human-readable description in plain text.
+	ID              string     `json:"id,omitempty"`               // A unique identifier.
+	InboundAverage  int        `json:"inbound_average,omitempty"`  // The desired inbound average rate, in Mbps for network QoS.
+	InboundBurst    int        `json:"inbound_burst,omitempty"`    // The desired inbound burst size, in MB.
+	InboundPeak     int        `json:"inbound_peak,omitempty"`     // The desired inbound peak rate, in Mbps.
+	Name            string     `json:"name,omitempty"`             // A human-readable name in plain text.
+	OutboundAverage int        `json:"outbound_average,omitempty"` // The desired outbound average rate, in Mbps for network QoS.
+	OutboundBurst   int        `json:"outbound_burst,omitempty"`   // The desired outbound burst size, in MB.
+	OutboundPeak    int        `json:"outbound_peak,omitempty"`    // The desired outbound peak rate, in Mbps.
+	Type            QosType    `json:"type,omitempty"`             // The kind of resources the QoS can be assigned to.
+}
+
+// QosType enum
+//   - cpu
+//   - hostnetwork
+//   - network
+//   - storage
+type QosType string
+
+// Collections of the oVirt REST API.
+//
+// The engine wraps every listed resource into an object with a single field named after the resource,
+// e.g. GET /vms returns {"vm": [...]}. An empty collection is returned as {}.
+
+type Vms struct {
+	Vm []Vm `json:"vm,omitempty"`
+}
+
+func (c Vms) items() []Vm { return c.Vm }
+
+type Disks struct {
+	Disk []Disk `json:"disk,omitempty"`
+}
+
+func (c Disks) items() []Disk { return c.Disk }
+
+type DiskAttachments struct {
+	DiskAttachment []DiskAttachment `json:"disk_attachment,omitempty"`
+}
+
+func (c DiskAttachments) items() []DiskAttachment { return c.DiskAttachment }
+
+type StorageDomains struct {
+	StorageDomain []StorageDomain `json:"storage_domain,omitempty"`
+}
+
+func (c StorageDomains) items() []StorageDomain { return c.StorageDomain }
+
+type Hosts struct {
+	Host []Host `json:"host,omitempty"`
+}
+
+func (c Hosts) items() []Host { return c.Host }
+
+type Clusters struct {
+	Cluster []Cluster `json:"cluster,omitempty"`
+}
+
+func (c Clusters) items() []Cluster { return c.Cluster }
+
+type DataCenters struct {
+	DataCenter []DataCenter `json:"data_center,omitempty"`
+}
+
+func (c DataCenters) items() []DataCenter { return c.DataCenter }
+
+type Templates struct {
+	Template []Template `json:"template,omitempty"`
+}
+
+func (c Templates) items() []Template { return c.Template }
+
+type Snapshots struct {
+	Snapshot []Snapshot `json:"snapshot,omitempty"`
+}
+
+func (c Snapshots) items() []Snapshot { return c.Snapshot }
+
+type Nics struct {
+	Nic []Nic `json:"nic,omitempty"`
+}
+
+func (c Nics) items() []Nic { return c.Nic }
+
+type ReportedDevices struct {
+	ReportedDevice []ReportedDevice `json:"reported_device,omitempty"`
+}
+
+func (c ReportedDevices) items() []ReportedDevice { return c.ReportedDevice }
+
+type VnicProfiles struct {
+	VnicProfile []VnicProfile `json:"vnic_profile,omitempty"`
+}
+
+func (c VnicProfiles) items() []VnicProfile { return c.VnicProfile }
+
+type Networks struct {
+	Network []Network `json:"network,omitempty"`
+}
+
+func (c Networks) items() []Network { return c.Network }
+
+type NetworkAttachments struct {
+	NetworkAttachment []NetworkAttachment `json:"network_attachment,omitempty"`
+}
+
+func (c NetworkAttachments) items() []NetworkAttachment { return c.NetworkAttachment }
+
+type Qoss struct {
+	Qos []Qos `json:"qos,omitempty"`
+}
+
+func (c Qoss) items() []Qos { return c.Qos }
+
+// Fault is returned by the engine in the body of an error response.
+type Fault struct {
+	Detail string `json:"detail,omitempty"` // Detailed description of the error.
+	Reason string `json:"reason,omitempty"` // Short description of the error.
 }
@@ -4,78 +4,76 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	neturl "net/url"
 	"os"
-	"strings"
-
-	"golang.org/x/oauth2"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
-const ovirtUrl = "ovirttest01.pkcht.ru"
 const apiUrl = "/ovirt-engine/api"
 const tokenURL = "/ovirt-engine/sso/oauth/token"
 
 func main() {
-	var user string = "admin@internal"
-	var scope = []string{"ovirt-app-api"}
-	var ovirtAPIurl string = ovirtUrl + apiUrl
-	ctx := context.Background()
-	ovirtPass := os.Getenv("OVIRT_PASS")
-	conf := &oauth2.Config{
-		ClientID:     user,
-		ClientSecret: ovirtPass,
-		Scopes:       scope,
-		Endpoint: oauth2.Endpoint{
-			AuthURL:   "https://" + ovirtUrl + apiUrl,
-			TokenURL:  "https://" + ovirtUrl + tokenURL,
-			AuthStyle: oauth2.AuthStyleInParams,
-		},
-	}
-
-	// Redirect user to consent page to ask for permission
-	// for the scopes specified above.
-	url := conf.AuthCodeURL("state", oauth2.AccessTypeOffline)
-	fmt.Printf("Visit the URL for the auth dialog: %v\n", url)
-
-	// Use the custom HTTP client when requesting a token.
-	tlsClient := &http.Client{Transport: tlsTranstort()}
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, tlsClient)
-	token, err := conf.PasswordCredentialsToken(ctx, conf.ClientID, conf.ClientSecret)
+	cfg, err := loadConfig(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Printf("access_token: %v\nTokenType: %v\nExpiry: %v", token.AccessToken, token.TokenType, token.Expiry)
-	client := conf.Client(ctx, token)
 
-	vms, err := vmsList(client, ovirtAPIurl)
-	if err != nil {
-		log.Fatal(err)
+	if cfg.Mode == modeExporter {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := runExporter(ctx, cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-	fmt.Print(vms)
 
-	vmDiskList, err := vmsDisks(client, ovirtAPIurl)
-	if err != nil {
-		log.Fatal(err)
+	if cfg.Output.Format == formatNDJSON {
+		// Rows are written while the inventory is collected.
+		inv, failed, err := streamNDJSON(cfg.Output, func(sink rowSink) (*inventory, []string) {
+			return inventoryEngines(context.Background(), cfg, sink)
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		logInventory(cfg, inv, failed)
+		return
 	}
-	fmt.Print(vmDiskList)
 
-	disksForVms, err := diskAttachments(client, ovirtAPIurl, vms)
-	if err != nil {
+	inv, failed := inventoryEngines(context.Background(), cfg, nil)
+	logInventory(cfg, inv, failed)
+	if err := writeOutput(cfg.Output, inv); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	fmt.Print(disksForVms)
-
-	vmsStats := composeStats(vms, vmDiskList, disksForVms)
-	fmt.Print(vmsStats)
+// func logInventory - log warnings of the inventory, exit if all engines failed
+func logInventory(cfg *config, inv *inventory, failed []string) {
+	if len(failed) == len(cfg.engines()) {
+		log.Fatal("inventory failed on all engines")
+	}
+	logLowSpace(inv.StorageDomains)
+	logOldSnapshots(inv.Snapshots)
+	logDuplicateMACs(inv.DuplicateMACs)
+	logUnusedNetworks(inv.Networks)
 }
 
-func getRequest(client *http.Client, url string) ([]byte, error) {
-	resp, err := client.Get(url)
+func getRequest(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Without Accept header the engine responds with XML.
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
 	if err != nil {
 		log.Println(err)
 		return nil, err
@@ -87,71 +85,275 @@ func getRequest(client *http.Client, url string) ([]byte, error) {
 		log.Println(err)
 		return nil, err
 	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newAPIError(resp, body)
+	}
 	return body, nil
 }
 
-func vmsList(client *http.Client, url string) ([]Vm, error) {
+// Links followed by vmsList in fetchFollow mode, every VM comes with its disks, NICs and reported devices.
+const vmFollowLinks = "disk_attachments.disk,nics,reported_devices"
 
-	url = url + "/vms"
+// func vmsList - fetch VMs page by page and pass every page to fn
+//
+//   - follow - list of links to fill in the same request, empty for none
+//   - search - oVirt search query, e.g. "cluster=prod and status=up", empty for all VMs
+//   - pageSize - count of VMs in a page, 0 to fetch all VMs in one request
+func vmsList(ctx context.Context, client *http.Client, url string, follow string, search string, pageSize int, fn func([]Vm) error) error {
+	query := neturl.Values{}
+	if follow != "" {
+		query.Set("follow", follow)
+	}
+	err := getPages[Vms](ctx, client, url+"/vms", query, search, pageSize, fn)
+	return searchError(search, err)
+}
 
-	body, err := getRequest(client, url)
-	if err != nil {
-		return nil, err
+// func vmsDisks - fetch disks, search is oVirt search query, empty for all disks.
+// All matched disks are kept in memory, unlike VMs they are looked up by every page of VMs.
+func vmsDisks(ctx context.Context, client *http.Client, url string, search string, pageSize int) ([]Disk, error) {
+	var disks []Disk
+	err := getPages[Disks](ctx, client, url+"/disks", nil, search, pageSize, func(page []Disk) error {
+		disks = append(disks, page...)
+		return nil
+	})
+	return disks, searchError(search, err)
+}
+
+// func storageDomainsList - fetch storage domains
+func storageDomainsList(ctx context.Context, client *http.Client, url string, pageSize int) ([]StorageDomain, error) {
+	var domains []StorageDomain
+	err := getPages[StorageDomains](ctx, client, url+"/storagedomains", nil, "", pageSize, func(page []StorageDomain) error {
+		domains = append(domains, page...)
+		return nil
+	})
+	return domains, err
+}
+
+// func hostsList - fetch hosts, follow - list of links to fill in the same request, empty for none
+func hostsList(ctx context.Context, client *http.Client, url string, follow string, pageSize int) ([]Host, error) {
+	query := neturl.Values{}
+	if follow != "" {
+		query.Set("follow", follow)
 	}
-	var vms []Vm
-	if err := json.Unmarshal(body, &vms); err != nil {
-		return nil, err
+	var hosts []Host
+	err := getPages[Hosts](ctx, client, url+"/hosts", query, "", pageSize, func(page []Host) error {
+		hosts = append(hosts, page...)
+		return nil
+	})
+	return hosts, err
+}
+
+// func templatesList - fetch templates, follow - list of links to fill in the same request, empty for none
+func templatesList(ctx context.Context, client *http.Client, url string, follow string, pageSize int) ([]Template, error) {
+	query := neturl.Values{}
+	if follow != "" {
+		query.Set("follow", follow)
 	}
-	return vms, nil
+	var templates []Template
+	err := getPages[Templates](ctx, client, url+"/templates", query, "", pageSize, func(page []Template) error {
+		templates = append(templates, page...)
+		return nil
+	})
+	return templates, err
 }
 
-func vmsDisks(client *http.Client, url string) ([]Disk, error) {
-	url = url + "/vmdisks"
-	body, err := getRequest(client, url)
-	if err != nil {
-		return nil, err
+// func clustersList - fetch clusters
+func clustersList(ctx context.Context, client *http.Client, url string) ([]Cluster, error) {
+	return getCollection[Clusters](ctx, client, url+"/clusters")
+}
+
+// func dataCentersList - fetch data centers
+func dataCentersList(ctx context.Context, client *http.Client, url string) ([]DataCenter, error) {
+	return getCollection[DataCenters](ctx, client, url+"/datacenters")
+}
+
+// func vnicProfilesList - fetch vNIC profiles
+func vnicProfilesList(ctx context.Context, client *http.Client, url string) ([]VnicProfile, error) {
+	return getCollection[VnicProfiles](ctx, client, url+"/vnicprofiles")
+}
+
+// func networksList - fetch logical networks
+func networksList(ctx context.Context, client *http.Client, url string) ([]Network, error) {
+	return getCollection[Networks](ctx, client, url+"/networks")
+}
+
+// func hostsNetworkAttachments - fetch network attachments of every host with up to parallelism concurrent requests.
+// Hosts failed to fetch network attachments of are logged and left without them.
+func hostsNetworkAttachments(ctx context.Context, client *http.Client, url string, hosts []Host, parallelism int) {
+	results, errs := forEach(hosts, parallelism, func(host Host) ([]NetworkAttachment, error) {
+		return getCollection[NetworkAttachments](ctx, client, url+"/hosts/"+host.ID+"/networkattachments")
+	})
+	for i := range hosts {
+		if errs[i] != nil {
+			log.Printf("host %s (%s): %v", hosts[i].Name, hosts[i].ID, errs[i])
+			continue
+		}
+		hosts[i].NetworkAttachments = results[i]
 	}
-	var vmdisks []Disk
-	if err := json.Unmarshal(body, &vmdisks); err != nil {
-		return nil, err
+}
+
+// func qosList - fetch QoS definitions of every data center
+func qosList(ctx context.Context, client *http.Client, url string, dataCenters []DataCenter) ([]Qos, error) {
+	var qoss []Qos
+	for _, dc := range dataCenters {
+		dcQoss, err := getCollection[Qoss](ctx, client, url+"/datacenters/"+dc.ID+"/qoss")
+		if err != nil {
+			return nil, err
+		}
+		qoss = append(qoss, dcQoss...)
+	}
+	return qoss, nil
+}
+
+// func searchError - explain the engine refused the search query
+func searchError(search string, err error) error {
+	if search != "" && errors.Is(err, errBadRequest) {
+		return fmt.Errorf("invalid search query %q: %w", search, err)
 	}
-	return vmdisks, nil
+	return err
 }
 
-func diskAttachments(client *http.Client, url string, vms []Vm) ([]vmDisks, error) {
+// func diskAttachments - fetch disk attachments of every VM with up to parallelism concurrent requests
+//
+// The result keeps the order of vms. A failure on a VM doesn't abort the others, the failed VMs
+// are skipped and returned as vmErrors. VMs removed after the VMs list was fetched are logged and skipped.
+func diskAttachments(ctx context.Context, client *http.Client, url string, vms []Vm, parallelism int) ([]vmDisks, error) {
+	results, errs := forEach(vms, parallelism, func(vm Vm) ([]DiskAttachment, error) {
+		return getCollection[DiskAttachments](ctx, client, url+"/vms/"+vm.ID+"/diskattachments")
+	})
+	return collectVmResults(vms, results, errs, func(vm Vm, attachments []DiskAttachment) vmDisks {
+		return vmDisks{vmID: vm.ID, diskAttachments: attachments}
+	})
+}
 
-	var vmsDisksAttachements []vmDisks
-	for _, vm := range vms {
-		url = url + "/vms/" + vm.ID + "/diskattachments"
-		body, err := getRequest(client, url)
+// func vmsSnapshots - fetch snapshots of every VM with up to parallelism concurrent requests
+//
+// With followDisks the disks of snapshots come in the same request, otherwise they are fetched
+// with a request per snapshot. The active snapshot, the current state of VM, has no disks of its own.
+// A snapshot removed after the snapshots list was fetched is logged and skipped, the other failures
+// are handled the same way as in diskAttachments.
+func vmsSnapshots(ctx context.Context, client *http.Client, url string, vms []Vm, followDisks bool, parallelism int) ([]vmSnapshots, error) {
+	results, errs := forEach(vms, parallelism, func(vm Vm) ([]Snapshot, error) {
+		snapshotsURL := url + "/vms/" + vm.ID + "/snapshots"
+		if followDisks {
+			return getCollection[Snapshots](ctx, client, snapshotsURL+"?follow=disks")
+		}
+		snapshots, err := getCollection[Snapshots](ctx, client, snapshotsURL)
 		if err != nil {
 			return nil, err
 		}
-		var attachments []DiskAttachment
-		if err := json.Unmarshal(body, &attachments); err != nil {
-			return nil, err
+		found := snapshots[:0]
+		for _, snapshot := range snapshots {
+			if snapshot.SnapshotType != "active" {
+				snapshot.Disks, err = getCollection[Disks](ctx, client, snapshotsURL+"/"+snapshot.ID+"/disks")
+				if errors.Is(err, errNotFound) {
+					log.Printf("VM %s: snapshot %s: %v", vm.ID, snapshot.ID, err)
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+			found = append(found, snapshot)
 		}
+		return found, nil
+	})
+	return collectVmResults(vms, results, errs, func(vm Vm, snapshots []Snapshot) vmSnapshots {
+		return vmSnapshots{vmID: vm.ID, snapshots: snapshots}
+	})
+}
+
+// func vmsNics - fetch NICs and devices reported by the guest agent of every VM with up to parallelism
+// concurrent requests. Failures are handled the same way as in diskAttachments.
+func vmsNics(ctx context.Context, client *http.Client, url string, vms []Vm, parallelism int) ([]vmNics, error) {
+	results, errs := forEach(vms, parallelism, func(vm Vm) (vmNics, error) {
+		nics, err := getCollection[Nics](ctx, client, url+"/vms/"+vm.ID+"/nics")
+		if err != nil {
+			return vmNics{}, err
+		}
+		devices, err := getCollection[ReportedDevices](ctx, client, url+"/vms/"+vm.ID+"/reporteddevices")
+		if err != nil {
+			return vmNics{}, err
+		}
+		return vmNics{vmID: vm.ID, nics: nics, reportedDevices: devices}, nil
+	})
+	return collectVmResults(vms, results, errs, func(_ Vm, nics vmNics) vmNics {
+		return nics
+	})
+}
 
-		vmsDisksAttachements = append(vmsDisksAttachements, vmDisks{vmID: vm.ID, diskAttachments: attachments})
+// func collectVmResults - wrap results of forEach over vms, in the order of vms
+//
+// VMs removed after the VMs list was fetched are logged and skipped, the other failed VMs
+// are skipped and returned as vmErrors.
+func collectVmResults[T, R any](vms []Vm, results []T, errs []error, wrap func(Vm, T) R) ([]R, error) {
+	collected := make([]R, 0, len(vms))
+	var failed vmErrors
+	for i, vm := range vms {
+		switch {
+		case errors.Is(errs[i], errNotFound):
+			// VM is removed after the VMs list was fetched.
+			log.Printf("VM %s: %v", vm.ID, errs[i])
+		case errs[i] != nil:
+			failed = append(failed, vmError{VmID: vm.ID, Name: vm.Name, Err: errs[i]})
+		default:
+			collected = append(collected, wrap(vm, results[i]))
+		}
+	}
+	if len(failed) > 0 {
+		return collected, failed
 	}
-	return vmsDisksAttachements, nil
+	return collected, nil
 }
 
-func tlsTranstort() *http.Transport {
-	return &http.Transport{
-		TLSClientConfig: tlsConfig(),
+// func forEach - call fetch for every item with up to parallelism concurrent calls, results and errors keep the order of items
+func forEach[I, T any](items []I, parallelism int, fetch func(I) (T, error)) ([]T, []error) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	results := make([]T, len(items))
+	errs := make([]error, len(items))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = fetch(items[i])
+			}
+		}()
 	}
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs
 }
 
-func tlsConfig() *tls.Config {
+func tlsTranstort(caFile string) (*http.Transport, error) {
+	tlsConf, err := tlsConfig(caFile)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Transport{
+		TLSClientConfig: tlsConf,
+	}, nil
+}
+
+func tlsConfig(caFile string) (*tls.Config, error) {
 
-	crt, err := os.ReadFile("./oVirt_CA/ovirt_ca.pem")
+	crt, err := os.ReadFile(caFile)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	rootCAs := x509.NewCertPool()
-	rootCAs.AppendCertsFromPEM(crt)
+	if !rootCAs.AppendCertsFromPEM(crt) {
+		return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+	}
 
 	return &tls.Config{
 		RootCAs:            rootCAs,
@@ -178,11 +380,16 @@ func tlsConfig() *tls.Config {
 		DynamicRecordSizingDisabled: false,
 		Renegotiation:               0,
 		KeyLogWriter:                nil,
-	}
+	}, nil
 }
 
-func composeStats(vms []Vm, diskList []Disk, disksForVms []vmDisks) []vmStats {
-	var vmsStats = make([]vmStats, 0, len(vms))
+// func composeStats - compose VMs stats
+//
+//   - counted - shared disks already counted in stats of other VMs, it is updated with shared disks of vms
+//   - tiers - tiers of storage domains of the engine
+//   - now - the moment for derived values such as age and uptime
+func composeStats(vms []Vm, diskList []Disk, disksForVms []vmDisks, counted sharedDisks, tiers storageTiers, now time.Time) []vmStats {
+	var vmsStats = make([]vmStats, len(vms))
 	// Create Map of Disk
 	disksMap := diskMap(diskList)
 	// Create Map for attached disks to VMs
@@ -190,8 +397,7 @@ func composeStats(vms []Vm, diskList []Disk, disksForVms []vmDisks) []vmStats {
 
 	for i, v := range vms {
 		vmsStats[i].Comment = v.Comment
-		// TODO: make a request to oVirt API and check out how Vm.Cpu.Cores looks like.
-		// vmsStats[i].Cpu = v.Cpu.Cores
+		vmsStats[i].Cpu = vCpus(v.Cpu.Topology)
 		vmsStats[i].CreationTime = v.CreationTime
 		vmsStats[i].Description = v.Description
 		vmsStats[i].FQDN = v.FQDN
@@ -200,21 +406,114 @@ func composeStats(vms []Vm, diskList []Disk, disksForVms []vmDisks) []vmStats {
 		vmsStats[i].Name = v.Name
 		vmsStats[i].OS = v.OS.Type
 		vmsStats[i].RunOnce = v.RunOnce
-		vmsStats[i].SerialNumber = v.StatusDetail
+		vmsStats[i].SerialNumber = v.SerialNumber.Value
 		vmsStats[i].StartTime = v.StartTime
 		vmsStats[i].Status = v.Status
 		vmsStats[i].StatusDetail = v.StatusDetail
 		vmsStats[i].StopReason = v.StopReason
 		vmsStats[i].StopTime = v.StopTime
+		vmsStats[i].AgeDays = ageDays(v.CreationTime, now)
+		vmsStats[i].Uptime = uptime(v, now)
 
-		vmsStats[i].HddDiskSize = diskSizeCalculation(vmsDisksMap[v.ID], disksMap, false)
-		vmsStats[i].SsdDiskSize = diskSizeCalculation(vmsDisksMap[v.ID], disksMap, true)
+		disks, shared := attachedDisks(vmsDisksMap[v.ID], disksMap, counted)
+		vmsStats[i].ProvisionedSize, vmsStats[i].ActualSize, vmsStats[i].TotalSize = diskSizes(disks)
+		vmsStats[i].ThinRatio = thinRatio(vmsStats[i].ProvisionedSize, vmsStats[i].ActualSize)
+		vmsStats[i].DiskSizeByTier = tierSizes(disks, tiers)
 		vmsStats[i].VmDisksCount = len(vmsDisksMap[v.ID])
+		vmsStats[i].SharedDisksCount = shared
 
 	}
 	return vmsStats
 }
 
+// func vCpus - count of virtual CPUs of the topology, sockets × cores × threads.
+// An unset part of the topology counts as 1, the empty topology is 0 CPUs.
+func vCpus(topology CpuTopology) int {
+	if topology == (CpuTopology{}) {
+		return 0
+	}
+	count := 1
+	for _, n := range []int{topology.Sockets, topology.Cores, topology.Threads} {
+		if n > 0 {
+			count *= n
+		}
+	}
+	return count
+}
+
+// func ageDays - full days passed since created, 0 if the date is unknown
+func ageDays(created Timestamp, now time.Time) int {
+	if created.IsZero() {
+		return 0
+	}
+	return int(now.Sub(created.Time) / (24 * time.Hour))
+}
+
+// func uptime - seconds passed since the VM start, 0 if the VM process is not running
+func uptime(vm Vm, now time.Time) int64 {
+	if vm.StartTime.IsZero() || !vmRunning(vm.Status) {
+		return 0
+	}
+	return int64(now.Sub(vm.StartTime.Time) / time.Second)
+}
+
+// func vmRunning - helps to determine is the virtual machine process running or not
+func vmRunning(status VmStatus) bool {
+	switch status {
+	case "up", "powering_up", "powering_down", "reboot_in_progress", "migrating", "paused", "saving_state", "not_responding":
+		return true
+	}
+	return false
+}
+
+// Links followed when templates are fetched in fetchFollow mode.
+const templateFollowLinks = "disk_attachments.disk"
+
+// Links followed when hosts are fetched in fetchFollow mode.
+const hostFollowLinks = "network_attachments"
+
+// func linkedTemplateDisks - collect disks and disk attachments of templates fetched with followed links
+func linkedTemplateDisks(templates []Template) ([]Disk, []vmDisks) {
+	var diskList []Disk
+	var disksForTemplates = make([]vmDisks, 0, len(templates))
+	for _, t := range templates {
+		for _, attachment := range t.DiskAttachments {
+			if attachment.Disk.ID != "" {
+				diskList = append(diskList, attachment.Disk)
+			}
+		}
+		disksForTemplates = append(disksForTemplates, vmDisks{vmID: t.ID, diskAttachments: t.DiskAttachments})
+	}
+	return diskList, disksForTemplates
+}
+
+// func linkedNics - collect NICs and reported devices of VMs fetched with followed links
+func linkedNics(vms []Vm) []vmNics {
+	var nicsForVms = make([]vmNics, 0, len(vms))
+	for _, vm := range vms {
+		nicsForVms = append(nicsForVms, vmNics{vmID: vm.ID, nics: vm.Nics, reportedDevices: vm.ReportedDevices})
+	}
+	return nicsForVms
+}
+
+// func linkedDisks - collect disks and disk attachments of VMs fetched with followed links.
+// A disk shared by several VMs is listed once.
+func linkedDisks(vms []Vm) ([]Disk, []vmDisks) {
+	var diskList []Disk
+	var disksForVms = make([]vmDisks, 0, len(vms))
+	seen := make(map[string]bool)
+	for _, vm := range vms {
+		for _, attachment := range vm.DiskAttachments {
+			if disk := attachment.Disk; disk.ID != "" && !seen[disk.ID] {
+				seen[disk.ID] = true
+				diskList = append(diskList, disk)
+			}
+		}
+		disksForVms = append(disksForVms, vmDisks{vmID: vm.ID, diskAttachments: vm.DiskAttachments})
+	}
+	return diskList, disksForVms
+}
+
 // Prepare Map for easiest search attached disks to VM
 func vmDisksMap(disksForVms []vmDisks) map[string][]DiskAttachment {
 	var vmsDisksMap = make(map[string][]DiskAttachment)
@@ -233,27 +532,48 @@ func diskMap(diskList []Disk) map[string]Disk {
 	return disksMap
 }
 
-// func diskSizeCalculation - calculate disks size grouped by StorageType HDD or SSD
-//
-//   - attachedDisks - Slise of attacet disks to VM
-//   - disks - Map of virtual disks
-//   - ssd
+// IDs of shared disks already counted in stats of a VM.
+// A disk shared by several VMs is counted once, in stats of the first VM it is seen on.
+type sharedDisks map[string]bool
+
+// func attachedDisks - disks the attachments point to, skipping shared disks already counted
 //
-// if ssdStorage == ssd - sum will be calculated.
-func diskSizeCalculation(attachedDisks []DiskAttachment, disks map[string]Disk, ssd bool) int {
-	var diskSize int
-	for _, v := range attachedDisks {
-		// calculate discs size
-		if ssdStorage(disks[v.ID].LunStorage.Description) == ssd {
-			diskSize += disks[v.ID].InitialSize
+// Disks missing in disks map, e.g. filtered out by the disk search, are skipped.
+// The count of shared disks attached to VM is returned too, counted or not.
+func attachedDisks(attachments []DiskAttachment, disks map[string]Disk, counted sharedDisks) ([]Disk, int) {
+	var list []Disk
+	var shared int
+	for _, attachment := range attachments {
+		disk, ok := disks[attachment.Disk.ID]
+		if !ok {
+			continue
 		}
-
+		if disk.Shareable {
+			shared++
+			if counted[disk.ID] {
+				continue
+			}
+			counted[disk.ID] = true
+		}
+		list = append(list, disk)
 	}
-	return diskSize
+	return list, shared
 }
 
-// func ssdStorage - helps to determine is virtual disk on SSD storage or not
-func ssdStorage(description string) bool {
+// func diskSizes - total provisioned (virtual), actual (allocated) and total (with snapshots) size of disks
+func diskSizes(disks []Disk) (provisioned, actual, total int) {
+	for _, disk := range disks {
+		provisioned += disk.ProvisionedSize
+		actual += disk.ActualSize
+		total += disk.TotalSize
+	}
+	return provisioned, actual, total
+}
 
-	return strings.Contains(strings.ToLower(description), "ssd")
+// func thinRatio - thin provisioning ratio, how many times the provisioned size exceeds the actual size
+func thinRatio(provisioned, actual int) float64 {
+	if actual == 0 {
+		return 0
+	}
+	return ratio(provisioned, actual)
 }
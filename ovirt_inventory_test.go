@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Tiers of test storage domains by the default rules: sd-ssd is on ssd tier, sd-hdd is on hdd tier.
+func testStorageTiers(t *testing.T) storageTiers {
+	t.Helper()
+	classifier, err := newTierClassifier(tiersConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newStorageTiers(classifier, []StorageDomain{{ID: "sd-ssd", Name: "FC-SSD-01"}, {ID: "sd-hdd", Name: "NFS-01"}})
+}
+
+func TestComposeStats(t *testing.T) {
+	tiers := testStorageTiers(t)
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	created := Timestamp{now.Add(-10*24*time.Hour - time.Hour)}
+	started := Timestamp{now.Add(-90 * time.Minute)}
+	stopped := Timestamp{now.Add(-time.Hour)}
+
+	tests := []struct {
+		name        string
+		vm          Vm
+		disks       []Disk
+		attachments []DiskAttachment
+		want        vmStats
+	}{
+		{
+			name: "plain fields",
+			vm: Vm{
+				ID:           "vm1",
+				Name:         "web01",
+				Comment:      "frontend",
+				Description:  "nginx",
+				FQDN:         "web01.example.com",
+				Memory:       4 << 30,
+				OS:           OperatingSystem{Type: "rhel_8x64"},
+				RunOnce:      true,
+				Status:       "down",
+				StatusDetail: "shutdown",
+				StopReason:   "maintenance",
+			},
+			want: vmStats{
+				ID:           "vm1",
+				Name:         "web01",
+				Comment:      "frontend",
+				Description:  "nginx",
+				FQDN:         "web01.example.com",
+				Memory:       4 << 30,
+				OS:           "rhel_8x64",
+				RunOnce:      true,
+				Status:       "down",
+				StatusDetail: "shutdown",
+				StopReason:   "maintenance",
+			},
+		},
+		{
+			name: "cpu topology",
+			vm:   Vm{ID: "vm1", Cpu: Cpu{Topology: CpuTopology{Sockets: 2, Cores: 4, Threads: 2}}},
+			want: vmStats{ID: "vm1", Cpu: 16},
+		},
+		{
+			name: "serial number",
+			vm:   Vm{ID: "vm1", SerialNumber: SerialNumber{Policy: "custom", Value: "SN-42"}, StatusDetail: "detail"},
+			want: vmStats{ID: "vm1", SerialNumber: "SN-42", StatusDetail: "detail"},
+		},
+		{
+			name: "running vm dates",
+			vm:   Vm{ID: "vm1", Status: "up", CreationTime: created, StartTime: started},
+			want: vmStats{ID: "vm1", Status: "up", CreationTime: created, StartTime: started, AgeDays: 10, Uptime: 90 * 60},
+		},
+		{
+			name: "stopped vm dates",
+			vm:   Vm{ID: "vm1", Status: "down", CreationTime: created, StartTime: started, StopTime: stopped},
+			want: vmStats{ID: "vm1", Status: "down", CreationTime: created, StartTime: started, StopTime: stopped, AgeDays: 10},
+		},
+		{
+			name: "disks by tier",
+			vm:   Vm{ID: "vm1"},
+			disks: []Disk{
+				{ID: "d1", ProvisionedSize: 10, StorageDomains: []StorageDomain{{ID: "sd-ssd"}}},
+				{ID: "d2", ProvisionedSize: 20, StorageDomains: []StorageDomain{{ID: "sd-hdd"}}},
+				{ID: "d3", ProvisionedSize: 30, StorageDomains: []StorageDomain{{ID: "sd-hdd"}}},
+				{ID: "d4", ProvisionedSize: 40},
+			},
+			attachments: []DiskAttachment{{ID: "a1", Disk: Disk{ID: "d1"}}, {ID: "a2", Disk: Disk{ID: "d2"}}, {ID: "a3", Disk: Disk{ID: "d3"}}},
+			want:        vmStats{ID: "vm1", ProvisionedSize: 60, DiskSizeByTier: map[string]int{"ssd": 10, "hdd": 50}, VmDisksCount: 3},
+		},
+		{
+			name: "disk sizes",
+			vm:   Vm{ID: "vm1"},
+			disks: []Disk{
+				{ID: "d1", ProvisionedSize: 100, ActualSize: 20, TotalSize: 30, InitialSize: 1},
+				{ID: "d2", ProvisionedSize: 50, ActualSize: 50, TotalSize: 50},
+			},
+			attachments: []DiskAttachment{{ID: "a1", Disk: Disk{ID: "d1"}}, {ID: "a2", Disk: Disk{ID: "d2"}}},
+			want:        vmStats{ID: "vm1", ProvisionedSize: 150, ActualSize: 70, TotalSize: 80, ThinRatio: 2.14, DiskSizeByTier: map[string]int{"hdd": 150}, VmDisksCount: 2},
+		},
+		{
+			name:        "unknown disk",
+			vm:          Vm{ID: "vm1"},
+			attachments: []DiskAttachment{{ID: "a1", Disk: Disk{ID: "d1"}}},
+			want:        vmStats{ID: "vm1", VmDisksCount: 1},
+		},
+		{
+			name: "no disks",
+			vm:   Vm{ID: "vm1"},
+			want: vmStats{ID: "vm1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disksForVms := []vmDisks{{vmID: tt.vm.ID, diskAttachments: tt.attachments}}
+			got := composeStats([]Vm{tt.vm}, tt.disks, disksForVms, sharedDisks{}, tiers, now)
+			if len(got) != 1 {
+				t.Fatalf("composeStats() returned %d stats, want 1", len(got))
+			}
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("composeStats() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestComposeStatsKeepsOrder(t *testing.T) {
+	vms := []Vm{{ID: "vm1"}, {ID: "vm2"}, {ID: "vm3"}}
+	got := composeStats(vms, nil, nil, sharedDisks{}, testStorageTiers(t), time.Now())
+	if len(got) != len(vms) {
+		t.Fatalf("composeStats() returned %d stats, want %d", len(got), len(vms))
+	}
+	for i, vm := range vms {
+		if got[i].ID != vm.ID {
+			t.Errorf("composeStats()[%d].ID = %s, want %s", i, got[i].ID, vm.ID)
+		}
+	}
+}
+
+func TestComposeStatsSharedDisks(t *testing.T) {
+	disks := []Disk{
+		{ID: "own1", ProvisionedSize: 10, ActualSize: 5},
+		{ID: "shared", ProvisionedSize: 100, ActualSize: 50, Shareable: true},
+		{ID: "own2", ProvisionedSize: 20, ActualSize: 10},
+	}
+	attach := func(ids ...string) []DiskAttachment {
+		var attachments []DiskAttachment
+		for _, id := range ids {
+			attachments = append(attachments, DiskAttachment{ID: id, Disk: Disk{ID: id}})
+		}
+		return attachments
+	}
+	counted := sharedDisks{}
+	tiers := testStorageTiers(t)
+
+	// The shared disk is counted on the first VM only, also across pages of VMs.
+	page1 := composeStats([]Vm{{ID: "vm1"}}, disks, []vmDisks{{vmID: "vm1", diskAttachments: attach("own1", "shared")}}, counted, tiers, time.Now())
+	page2 := composeStats([]Vm{{ID: "vm2"}}, disks, []vmDisks{{vmID: "vm2", diskAttachments: attach("shared", "own2")}}, counted, tiers, time.Now())
+
+	tests := []struct {
+		got             vmStats
+		provisioned     int
+		actual          int
+		disksCount      int
+		sharedDiskCount int
+	}{
+		{page1[0], 110, 55, 2, 1},
+		{page2[0], 20, 10, 2, 1},
+	}
+	for _, tt := range tests {
+		if tt.got.ProvisionedSize != tt.provisioned || tt.got.ActualSize != tt.actual {
+			t.Errorf("%s: sizes = %d/%d, want %d/%d", tt.got.ID, tt.got.ProvisionedSize, tt.got.ActualSize, tt.provisioned, tt.actual)
+		}
+		if tt.got.VmDisksCount != tt.disksCount || tt.got.SharedDisksCount != tt.sharedDiskCount {
+			t.Errorf("%s: disks = %d, shared = %d, want %d, %d", tt.got.ID, tt.got.VmDisksCount, tt.got.SharedDisksCount, tt.disksCount, tt.sharedDiskCount)
+		}
+	}
+}
+
+func TestVCpus(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology CpuTopology
+		want     int
+	}{
+		{"empty", CpuTopology{}, 0},
+		{"single", CpuTopology{Sockets: 1, Cores: 1, Threads: 1}, 1},
+		{"sockets", CpuTopology{Sockets: 4, Cores: 1, Threads: 1}, 4},
+		{"full", CpuTopology{Sockets: 2, Cores: 8, Threads: 2}, 32},
+		{"unset threads", CpuTopology{Sockets: 2, Cores: 4}, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vCpus(tt.topology); got != tt.want {
+				t.Errorf("vCpus(%+v) = %d, want %d", tt.topology, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForEach(t *testing.T) {
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+	errOdd := errors.New("odd")
+	for _, parallelism := range []int{0, 1, 4, 100} {
+		t.Run(fmt.Sprint("parallelism ", parallelism), func(t *testing.T) {
+			var running, peak int32
+			results, errs := forEach(items, parallelism, func(i int) (string, error) {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				// Later items finish first, the results must keep the order of items anyway.
+				time.Sleep(time.Duration(len(items)-i) * 20 * time.Microsecond)
+				if i%2 == 1 {
+					return "", errOdd
+				}
+				return fmt.Sprint("item ", i), nil
+			})
+
+			limit := int32(parallelism)
+			if limit < 1 {
+				limit = 1
+			}
+			if peak > limit {
+				t.Errorf("%d concurrent calls, want at most %d", peak, limit)
+			}
+			if len(results) != len(items) || len(errs) != len(items) {
+				t.Fatalf("%d results and %d errors, want %d of each", len(results), len(errs), len(items))
+			}
+			for i := range items {
+				if i%2 == 1 {
+					if !errors.Is(errs[i], errOdd) || results[i] != "" {
+						t.Errorf("item %d: %q, %v, want the error", i, results[i], errs[i])
+					}
+				} else if errs[i] != nil || results[i] != fmt.Sprint("item ", i) {
+					t.Errorf("item %d: %q, %v, want %q", i, results[i], errs[i], fmt.Sprint("item ", i))
+				}
+			}
+		})
+	}
+}
+
+func TestLinkedFollow(t *testing.T) {
+	tests := []struct {
+		name         string
+		doc          string
+		wantDisks    []string
+		wantAttached map[string][]string
+		wantNics     map[string][]string // VM name to NIC name and IPv4 addresses.
+	}{
+		{
+			name: "shared disk",
+			doc: `{"vm": [
+				{"id": "vm1", "name": "db01",
+					"disk_attachments": {"disk_attachment": [
+						{"id": "d1", "disk": {"id": "d1", "alias": "shared", "shareable": "true", "provisioned_size": "1024"}},
+						{"id": "d2", "disk": {"id": "d2", "alias": "db01_os", "provisioned_size": "2048"}}
+					]},
+					"nics": {"nic": [{"id": "n1", "name": "nic1", "mac": {"address": "56:6F:1A:00:00:01"}}]},
+					"reported_devices": {"reported_device": [
+						{"type": "network", "mac": {"address": "56:6f:1a:00:00:01"},
+							"ips": {"ip": [{"address": "10.0.0.5", "version": "v4"}]}}
+					]}},
+				{"id": "vm2", "name": "db02",
+					"disk_attachments": {"disk_attachment": [
+						{"id": "d1", "disk": {"id": "d1", "alias": "shared", "shareable": "true", "provisioned_size": "1024"}}
+					]},
+					"nics": {"nic": [{"id": "n2", "name": "nic1", "mac": {"address": "56:6f:1a:00:00:02"}}]}}
+			]}`,
+			wantDisks:    []string{"d1", "d2"},
+			wantAttached: map[string][]string{"vm1": {"d1", "d2"}, "vm2": {"d1"}},
+			wantNics:     map[string][]string{"db01": {"nic1", "10.0.0.5"}, "db02": {"nic1"}},
+		},
+		{
+			name: "empty links",
+			doc: `{"vm": [
+				{"id": "vm1", "name": "web01", "disk_attachments": {}, "nics": {}}
+			]}`,
+			wantAttached: map[string][]string{"vm1": nil},
+			wantNics:     map[string][]string{"web01": nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var page Vms
+			if err := unmarshalOvirt([]byte(tt.doc), &page); err != nil {
+				t.Fatal(err)
+			}
+			vms := page.items()
+
+			diskList, disksForVms := linkedDisks(vms)
+			var disks []string
+			for _, d := range diskList {
+				disks = append(disks, d.ID)
+			}
+			if !reflect.DeepEqual(disks, tt.wantDisks) {
+				t.Errorf("disks = %v, want %v", disks, tt.wantDisks)
+			}
+			attached := make(map[string][]string)
+			for _, v := range disksForVms {
+				var ids []string
+				for _, a := range v.diskAttachments {
+					ids = append(ids, a.Disk.ID)
+				}
+				attached[v.vmID] = ids
+			}
+			if !reflect.DeepEqual(attached, tt.wantAttached) {
+				t.Errorf("attached disks = %v, want %v", attached, tt.wantAttached)
+			}
+
+			vmsStats := composeStats(vms, diskList, disksForVms, sharedDisks{}, testStorageTiers(t), time.Now())
+			composeNics(vmsStats, vms, linkedNics(vms), newNetworkIndex(nil, nil, nil))
+			nics := make(map[string][]string)
+			for _, vm := range vmsStats {
+				var got []string
+				for _, n := range vm.Nics {
+					got = append(got, n.Name)
+					got = append(got, n.IPv4...)
+				}
+				nics[vm.Name] = got
+			}
+			if !reflect.DeepEqual(nics, tt.wantNics) {
+				t.Errorf("NICs = %v, want %v", nics, tt.wantNics)
+			}
+		})
+	}
+}
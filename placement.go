@@ -0,0 +1,127 @@
+package main
+
+// Clusters, data centers and hosts of an engine by ID, to place VMs and hosts in the hierarchy.
+type placement struct {
+	clusters    map[string]Cluster
+	dataCenters map[string]DataCenter
+	hosts       map[string]string // Host ID to name.
+}
+
+// func newPlacement - index clusters, data centers and hosts by ID
+func newPlacement(clusters []Cluster, dataCenters []DataCenter, hosts []Host) placement {
+	p := placement{
+		clusters:    make(map[string]Cluster, len(clusters)),
+		dataCenters: make(map[string]DataCenter, len(dataCenters)),
+		hosts:       make(map[string]string, len(hosts)),
+	}
+	for _, c := range clusters {
+		p.clusters[c.ID] = c
+	}
+	for _, dc := range dataCenters {
+		p.dataCenters[dc.ID] = dc
+	}
+	for _, h := range hosts {
+		p.hosts[h.ID] = h.Name
+	}
+	return p
+}
+
+// func clusterNames - names of the cluster and of its data center
+func (p placement) clusterNames(clusterID string) (cluster string, dataCenter string) {
+	c, ok := p.clusters[clusterID]
+	if !ok {
+		return "", ""
+	}
+	return c.Name, p.dataCenters[c.DataCenter.ID].Name
+}
+
+// func placeVms - set cluster, data center and host of VMs, vmsStats are in the order of vms
+func (p placement) placeVms(vmsStats []vmStats, vms []Vm) {
+	for i, v := range vms {
+		vmsStats[i].Cluster, vmsStats[i].DataCenter = p.clusterNames(v.Cluster.ID)
+		vmsStats[i].Host = p.hosts[v.Host.ID]
+	}
+}
+
+// func placeHosts - set cluster and data center of hosts, hostsStats are in the order of hosts
+func (p placement) placeHosts(hostsStats []hostStats, hosts []Host) {
+	for i, h := range hosts {
+		hostsStats[i].Cluster, hostsStats[i].DataCenter = p.clusterNames(h.Cluster.ID)
+	}
+}
+
+// func addVm - add the VM resources to the roll-up
+func (r *rollup) addVm(v vmStats) {
+	r.VmsCount++
+	r.Cpu += v.Cpu
+	r.Memory += v.Memory
+	if vmRunning(v.Status) {
+		r.VmsRunning++
+		r.RunningCpu += v.Cpu
+		r.RunningMemory += v.Memory
+	}
+}
+
+// func addHost - add the host resources to the roll-up
+func (r *rollup) addHost(h hostStats) {
+	r.HostsCount++
+	r.HostCpuThreads += h.CpuThreads
+	r.HostMemory += h.Memory
+}
+
+// func composeClusterStats - roll up resources of placed VMs and hosts per cluster.
+// VMs and hosts are matched to clusters by name, cluster names are unique in an engine.
+func composeClusterStats(clusters []Cluster, p placement, vmsStats []vmStats, hostsStats []hostStats) []clusterStats {
+	var clustersStats = make([]clusterStats, len(clusters))
+	byName := make(map[string]*clusterStats, len(clusters))
+	for i, c := range clusters {
+		clustersStats[i].ID = c.ID
+		clustersStats[i].Name = c.Name
+		clustersStats[i].DataCenter = p.dataCenters[c.DataCenter.ID].Name
+		clustersStats[i].CpuType = c.CPU.Type
+		clustersStats[i].Version = versionString(c.Version)
+		byName[c.Name] = &clustersStats[i]
+	}
+	for _, v := range vmsStats {
+		if c, ok := byName[v.Cluster]; ok {
+			c.addVm(v)
+		}
+	}
+	for _, h := range hostsStats {
+		if c, ok := byName[h.Cluster]; ok {
+			c.addHost(h)
+		}
+	}
+	return clustersStats
+}
+
+// func composeDataCenterStats - roll up cluster stats per data center.
+// Clusters are matched to data centers by name, data center names are unique in an engine.
+func composeDataCenterStats(dataCenters []DataCenter, clustersStats []clusterStats) []dataCenterStats {
+	var dataCentersStats = make([]dataCenterStats, len(dataCenters))
+	byName := make(map[string]*dataCenterStats, len(dataCenters))
+	for i, dc := range dataCenters {
+		dataCentersStats[i].ID = dc.ID
+		dataCentersStats[i].Name = dc.Name
+		dataCentersStats[i].Status = dc.Status
+		dataCentersStats[i].Version = versionString(dc.Version)
+		byName[dc.Name] = &dataCentersStats[i]
+	}
+	for _, c := range clustersStats {
+		dc, ok := byName[c.DataCenter]
+		if !ok {
+			continue
+		}
+		dc.ClustersCount++
+		dc.HostsCount += c.HostsCount
+		dc.HostCpuThreads += c.HostCpuThreads
+		dc.HostMemory += c.HostMemory
+		dc.VmsCount += c.VmsCount
+		dc.VmsRunning += c.VmsRunning
+		dc.Cpu += c.Cpu
+		dc.Memory += c.Memory
+		dc.RunningCpu += c.RunningCpu
+		dc.RunningMemory += c.RunningMemory
+	}
+	return dataCentersStats
+}
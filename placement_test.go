@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollups(t *testing.T) {
+	dataCenters := []DataCenter{{ID: "dc1", Name: "Default", Status: "up"}}
+	clusters := []Cluster{
+		{ID: "c1", Name: "prod", DataCenter: DataCenter{ID: "dc1"}, CPU: Cpu{Type: "Intel Cascadelake Server Family"}},
+		{ID: "c2", Name: "test", DataCenter: DataCenter{ID: "dc1"}},
+	}
+	hosts := []Host{
+		{ID: "h1", Name: "hv01", Cluster: Cluster{ID: "c1"}, Memory: 256 << 30, CPU: Cpu{Topology: CpuTopology{Sockets: 2, Cores: 8, Threads: 2}}},
+		{ID: "h2", Name: "hv02", Cluster: Cluster{ID: "c2"}, Memory: 128 << 30, CPU: Cpu{Topology: CpuTopology{Sockets: 1, Cores: 8, Threads: 2}}},
+	}
+	vms := []Vm{
+		{ID: "vm1", Cluster: Cluster{ID: "c1"}, Host: Host{ID: "h1"}, Status: "up", Memory: 8 << 30, Cpu: Cpu{Topology: CpuTopology{Sockets: 4, Cores: 1, Threads: 1}}},
+		{ID: "vm2", Cluster: Cluster{ID: "c1"}, Status: "down", Memory: 4 << 30, Cpu: Cpu{Topology: CpuTopology{Sockets: 2, Cores: 1, Threads: 1}}},
+		{ID: "vm3", Cluster: Cluster{ID: "c2"}, Host: Host{ID: "h2"}, Status: "up", Memory: 2 << 30, Cpu: Cpu{Topology: CpuTopology{Sockets: 1, Cores: 1, Threads: 1}}},
+	}
+
+	place := newPlacement(clusters, dataCenters, hosts)
+	vmsStats := composeStats(vms, nil, nil, sharedDisks{}, testStorageTiers(t), time.Now())
+	place.placeVms(vmsStats, vms)
+	hostsStats := composeHostStats(hosts)
+	place.placeHosts(hostsStats, hosts)
+
+	if got := [3]string{vmsStats[0].DataCenter, vmsStats[0].Cluster, vmsStats[0].Host}; got != [3]string{"Default", "prod", "hv01"} {
+		t.Errorf("vm1 placement = %v", got)
+	}
+	if got := vmsStats[1].Host; got != "" {
+		t.Errorf("vm2 host = %q, want none", got)
+	}
+	if got := [2]string{hostsStats[1].DataCenter, hostsStats[1].Cluster}; got != [2]string{"Default", "test"} {
+		t.Errorf("hv02 placement = %v", got)
+	}
+
+	clustersStats := composeClusterStats(clusters, place, vmsStats, hostsStats)
+	wantProd := clusterStats{
+		ID: "c1", Name: "prod", DataCenter: "Default", CpuType: "Intel Cascadelake Server Family",
+		rollup: rollup{HostsCount: 1, HostCpuThreads: 32, HostMemory: 256 << 30, VmsCount: 2, VmsRunning: 1,
+			Cpu: 6, Memory: 12 << 30, RunningCpu: 4, RunningMemory: 8 << 30},
+	}
+	if !reflect.DeepEqual(clustersStats[0], wantProd) {
+		t.Errorf("composeClusterStats()[0] = %+v, want %+v", clustersStats[0], wantProd)
+	}
+
+	dataCentersStats := composeDataCenterStats(dataCenters, clustersStats)
+	wantDC := dataCenterStats{
+		ID: "dc1", Name: "Default", Status: "up", ClustersCount: 2,
+		rollup: rollup{HostsCount: 2, HostCpuThreads: 48, HostMemory: 384 << 30, VmsCount: 3, VmsRunning: 2,
+			Cpu: 7, Memory: 14 << 30, RunningCpu: 5, RunningMemory: 10 << 30},
+	}
+	if !reflect.DeepEqual(dataCentersStats[0], wantDC) {
+		t.Errorf("composeDataCenterStats()[0] = %+v, want %+v", dataCentersStats[0], wantDC)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// func composeSnapshotStats - compose stats of VM snapshots and sum them up in stats of VMs.
+// The active snapshot is the current state of VM, it is skipped.
+//
+// vmsStats are in the order of vms. A snapshot older than oldDays is flagged as old, 0 disables flagging.
+func composeSnapshotStats(vmsStats []vmStats, vms []Vm, snapshotsForVms []vmSnapshots, oldDays int, now time.Time) []snapshotStats {
+	vmsSnapshotsMap := make(map[string][]Snapshot, len(snapshotsForVms))
+	for _, s := range snapshotsForVms {
+		vmsSnapshotsMap[s.vmID] = s.snapshots
+	}
+
+	var snapshotsStats []snapshotStats
+	for i, v := range vms {
+		vmsStats[i].HasIllegalImages = v.HasIllegalImages
+		for _, s := range vmsSnapshotsMap[v.ID] {
+			if s.SnapshotType == "active" {
+				continue
+			}
+			stats := snapshotStats{
+				VmID:          v.ID,
+				VmName:        v.Name,
+				ID:            s.ID,
+				Description:   s.Description,
+				Date:          s.Date,
+				AgeDays:       ageDays(s.Date, now),
+				Type:          s.SnapshotType,
+				Status:        s.SnapshotStatus,
+				PersistMemory: s.PersistMemorystate,
+				DisksCount:    len(s.Disks),
+			}
+			_, stats.Size, _ = diskSizes(s.Disks)
+			stats.Old = oldDays > 0 && !s.Date.IsZero() && stats.AgeDays >= oldDays
+			snapshotsStats = append(snapshotsStats, stats)
+
+			vmsStats[i].SnapshotsCount++
+			vmsStats[i].SnapshotsSize += stats.Size
+			if stats.AgeDays > vmsStats[i].OldestSnapshotDays {
+				vmsStats[i].OldestSnapshotDays = stats.AgeDays
+			}
+		}
+	}
+	return snapshotsStats
+}
+
+// func logOldSnapshots - warn about snapshots older than the configured number of days
+func logOldSnapshots(snapshotsStats []snapshotStats) {
+	for _, s := range snapshotsStats {
+		if s.Old {
+			log.Printf("engine %s: VM %s: snapshot %q is %d days old, %d MiB", s.Engine, s.VmName, s.Description, s.AgeDays, s.Size>>20)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestComposeSnapshotStats(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) Timestamp { return Timestamp{now.Add(-time.Duration(days) * 24 * time.Hour)} }
+	vms := []Vm{{ID: "vm1", Name: "web01", HasIllegalImages: true}, {ID: "vm2", Name: "db01"}}
+	snapshotsForVms := []vmSnapshots{{vmID: "vm1", snapshots: []Snapshot{
+		{ID: "active", SnapshotType: "active", Date: daysAgo(0), Disks: []Disk{{ActualSize: 1000}}},
+		{ID: "s1", SnapshotType: "regular", Description: "before upgrade", Date: daysAgo(45), Disks: []Disk{{ActualSize: 100}, {ActualSize: 50}}},
+		{ID: "s2", SnapshotType: "regular", Date: daysAgo(3), PersistMemorystate: true, Disks: []Disk{{ActualSize: 10}}},
+	}}}
+
+	vmsStats := composeStats(vms, nil, nil, sharedDisks{}, testStorageTiers(t), now)
+	got := composeSnapshotStats(vmsStats, vms, snapshotsForVms, 30, now)
+
+	tests := []struct {
+		id      string
+		ageDays int
+		size    int
+		disks   int
+		old     bool
+	}{
+		{"s1", 45, 150, 2, true},
+		{"s2", 3, 10, 1, false},
+	}
+	if len(got) != len(tests) {
+		t.Fatalf("composeSnapshotStats() returned %d stats, want %d without the active snapshot", len(got), len(tests))
+	}
+	for i, tt := range tests {
+		s := got[i]
+		if s.ID != tt.id || s.VmName != "web01" || s.AgeDays != tt.ageDays || s.Size != tt.size || s.DisksCount != tt.disks || s.Old != tt.old {
+			t.Errorf("snapshot %d = %+v, want %+v", i, s, tt)
+		}
+	}
+
+	if vm := vmsStats[0]; !vm.HasIllegalImages || vm.SnapshotsCount != 2 || vm.SnapshotsSize != 160 || vm.OldestSnapshotDays != 45 {
+		t.Errorf("web01 = %+v, want 2 snapshots of 160 bytes, the oldest 45 days", vm)
+	}
+	if vm := vmsStats[1]; vm.SnapshotsCount != 0 || vm.OldestSnapshotDays != 0 {
+		t.Errorf("db01 = %+v, want no snapshots", vm)
+	}
+}
+
+func TestVmsSnapshots(t *testing.T) {
+	responses := map[string]string{
+		"/vms/vm1/snapshots":          `{"snapshot":[{"id":"s0","snapshot_type":"active"},{"id":"s1"},{"id":"s2"}]}`,
+		"/vms/vm1/snapshots/s2/disks": `{"disk":[{"id":"d1","provisioned_size":"1024"}]}`,
+		"/vms/vm3/snapshots":          `{"snapshot":[{"id":"s3"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch body, ok := responses[r.URL.Path]; {
+		case ok:
+			fmt.Fprint(w, body)
+		case r.URL.Path == "/vms/vm3/snapshots/s3/disks":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			// vm2 and snapshot s1 of vm1 are removed.
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vms := []Vm{{ID: "vm1", Name: "web01"}, {ID: "vm2", Name: "web02"}, {ID: "vm3", Name: "web03"}}
+	snapshots, err := vmsSnapshots(context.Background(), server.Client(), server.URL, vms, false, 2)
+
+	var failed vmErrors
+	if !errors.As(err, &failed) || len(failed) != 1 || failed[0].VmID != "vm3" {
+		t.Fatalf("vmsSnapshots() error = %v, want vmErrors of vm3", err)
+	}
+	if !errors.Is(failed[0].Err, errServer) {
+		t.Errorf("error of vm3 = %v, want errServer", failed[0].Err)
+	}
+	if len(snapshots) != 1 || snapshots[0].vmID != "vm1" {
+		t.Fatalf("vmsSnapshots() = %+v, want snapshots of vm1 only", snapshots)
+	}
+	got := snapshots[0].snapshots
+	if len(got) != 2 || got[0].ID != "s0" || got[1].ID != "s2" {
+		t.Fatalf("snapshots of vm1 = %+v, want s0 and s2", got)
+	}
+	if len(got[1].Disks) != 1 || got[1].Disks[0].ProvisionedSize != 1024 {
+		t.Errorf("disks of s2 = %+v, want d1 of 1024 bytes", got[1].Disks)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"math"
+)
+
+// Virtual machines and disks on storage domains of an engine, as far as they are fetched:
+// VMs matched by the search query and disks of the fetch mode.
+type domainUsage struct {
+	vms   map[string][]string // Storage domain ID to names of VMs.
+	disks map[string][]string // Storage domain ID to aliases of disks.
+	seen  map[string]bool     // Domain ID with VM or disk ID already listed.
+}
+
+func newDomainUsage() *domainUsage {
+	return &domainUsage{
+		vms:   make(map[string][]string),
+		disks: make(map[string][]string),
+		seen:  make(map[string]bool),
+	}
+}
+
+// func add - link the storage domains to the VMs and to the disks residing on them
+func (u *domainUsage) add(vms []Vm, diskList []Disk, disksForVms []vmDisks) {
+	disksMap := diskMap(diskList)
+	for _, disk := range diskList {
+		for _, domain := range disk.StorageDomains {
+			if key := domain.ID + "/disk/" + disk.ID; !u.seen[key] {
+				u.seen[key] = true
+				u.disks[domain.ID] = append(u.disks[domain.ID], diskName(disk))
+			}
+		}
+	}
+
+	vmsDisksMap := vmDisksMap(disksForVms)
+	for _, vm := range vms {
+		for _, attachment := range vmsDisksMap[vm.ID] {
+			for _, domain := range disksMap[attachment.Disk.ID].StorageDomains {
+				if key := domain.ID + "/vm/" + vm.ID; !u.seen[key] {
+					u.seen[key] = true
+					u.vms[domain.ID] = append(u.vms[domain.ID], vm.Name)
+				}
+			}
+		}
+	}
+}
+
+// func diskName - alias of the disk, its ID if the alias is empty
+func diskName(disk Disk) string {
+	if disk.Alias != "" {
+		return disk.Alias
+	}
+	if disk.Name != "" {
+		return disk.Name
+	}
+	return disk.ID
+}
+
+// func composeStorageDomainStats - compose capacity stats of storage domains and flag domains low on space
+func composeStorageDomainStats(domains []StorageDomain, tiers storageTiers, usage *domainUsage) []storageDomainStats {
+	var domainsStats = make([]storageDomainStats, len(domains))
+	for i, d := range domains {
+		domainsStats[i].ID = d.ID
+		domainsStats[i].Name = d.Name
+		domainsStats[i].Type = d.Type
+		domainsStats[i].StorageType = d.Storage.Type
+		domainsStats[i].Status = d.Status
+		domainsStats[i].Tier = tiers.domains[d.ID]
+		domainsStats[i].Available = d.Available
+		domainsStats[i].Used = d.Used
+		domainsStats[i].Committed = d.Committed
+		domainsStats[i].Total = d.Available + d.Used
+		domainsStats[i].UsedPercent = percent(d.Used, d.Available+d.Used)
+		domainsStats[i].OvercommitRatio = ratio(d.Committed, d.Available+d.Used)
+		domainsStats[i].WarningLowSpace = d.WarningLowSpaceIndicator
+		domainsStats[i].CriticalSpace = d.CriticalSpaceActionBlocker
+		domainsStats[i].LowSpace = lowSpace(d)
+		domainsStats[i].CriticalLow = criticalSpace(d)
+		domainsStats[i].VMs = usage.vms[d.ID]
+		domainsStats[i].Disks = usage.disks[d.ID]
+	}
+	return domainsStats
+}
+
+// func lowSpace - helps to determine is the free space of the domain below the warning indicator or not
+func lowSpace(d StorageDomain) bool {
+	total := d.Available + d.Used
+	if d.WarningLowSpaceIndicator <= 0 || total == 0 {
+		return false
+	}
+	return percent(d.Available, total) < float64(d.WarningLowSpaceIndicator)
+}
+
+// func criticalSpace - helps to determine is the free space of the domain below the critical space action blocker or not
+func criticalSpace(d StorageDomain) bool {
+	if d.CriticalSpaceActionBlocker <= 0 || d.Available+d.Used == 0 {
+		return false
+	}
+	// CriticalSpaceActionBlocker is set in GiB.
+	return d.Available < d.CriticalSpaceActionBlocker<<30
+}
+
+// func logLowSpace - warn about storage domains low on space
+func logLowSpace(domainsStats []storageDomainStats) {
+	for _, d := range domainsStats {
+		switch {
+		case d.CriticalLow:
+			log.Printf("engine %s: storage domain %s: free space %d GiB is below critical %d GiB", d.Engine, d.Name, d.Available>>30, d.CriticalSpace)
+		case d.LowSpace:
+			log.Printf("engine %s: storage domain %s: free space %.1f%% is below warning %d%%", d.Engine, d.Name, 100-d.UsedPercent, d.WarningLowSpace)
+		}
+	}
+}
+
+// func percent - part of total in percent, rounded to 0.1
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
+
+// func ratio - a to b ratio, rounded to 0.01
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return math.Round(float64(a)/float64(b)*100) / 100
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComposeStorageDomainStats(t *testing.T) {
+	tiers := testStorageTiers(t)
+	tests := []struct {
+		name   string
+		domain StorageDomain
+		want   storageDomainStats
+	}{
+		{
+			name:   "capacity",
+			domain: StorageDomain{ID: "sd-ssd", Name: "FC-SSD-01", Available: 60 << 30, Used: 40 << 30, Committed: 150 << 30},
+			want: storageDomainStats{ID: "sd-ssd", Name: "FC-SSD-01", Tier: "ssd", Available: 60 << 30, Used: 40 << 30, Committed: 150 << 30,
+				Total: 100 << 30, UsedPercent: 40, OvercommitRatio: 1.5},
+		},
+		{
+			name:   "low space",
+			domain: StorageDomain{ID: "sd-hdd", Available: 9 << 30, Used: 91 << 30, WarningLowSpaceIndicator: 10, CriticalSpaceActionBlocker: 5},
+			want: storageDomainStats{ID: "sd-hdd", Tier: "hdd", Available: 9 << 30, Used: 91 << 30, Total: 100 << 30, UsedPercent: 91,
+				WarningLowSpace: 10, CriticalSpace: 5, LowSpace: true},
+		},
+		{
+			name:   "critical space",
+			domain: StorageDomain{ID: "sd-hdd", Available: 4 << 30, Used: 96 << 30, WarningLowSpaceIndicator: 10, CriticalSpaceActionBlocker: 5},
+			want: storageDomainStats{ID: "sd-hdd", Tier: "hdd", Available: 4 << 30, Used: 96 << 30, Total: 100 << 30, UsedPercent: 96,
+				WarningLowSpace: 10, CriticalSpace: 5, LowSpace: true, CriticalLow: true},
+		},
+		{
+			name:   "no capacity reported",
+			domain: StorageDomain{ID: "sd-hdd", WarningLowSpaceIndicator: 10, CriticalSpaceActionBlocker: 5},
+			want:   storageDomainStats{ID: "sd-hdd", Tier: "hdd", WarningLowSpace: 10, CriticalSpace: 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := composeStorageDomainStats([]StorageDomain{tt.domain}, tiers, newDomainUsage())
+			if !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("composeStorageDomainStats() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainUsage(t *testing.T) {
+	disks := []Disk{
+		{ID: "d1", Alias: "web01_Disk1", StorageDomains: []StorageDomain{{ID: "sd-ssd"}}},
+		{ID: "d2", Alias: "web01_Disk2", StorageDomains: []StorageDomain{{ID: "sd-ssd"}}},
+		{ID: "d3", StorageDomains: []StorageDomain{{ID: "sd-hdd"}}},
+	}
+	vms := []Vm{{ID: "vm1", Name: "web01"}, {ID: "vm2", Name: "db01"}}
+	disksForVms := []vmDisks{
+		{vmID: "vm1", diskAttachments: []DiskAttachment{{Disk: Disk{ID: "d1"}}, {Disk: Disk{ID: "d2"}}}},
+		{vmID: "vm2", diskAttachments: []DiskAttachment{{Disk: Disk{ID: "d3"}}}},
+	}
+
+	usage := newDomainUsage()
+	// Disks of separate fetch mode are passed with every page, they are listed once.
+	usage.add(vms, disks, disksForVms)
+	usage.add(nil, disks, nil)
+
+	wantVMs := map[string][]string{"sd-ssd": {"web01"}, "sd-hdd": {"db01"}}
+	wantDisks := map[string][]string{"sd-ssd": {"web01_Disk1", "web01_Disk2"}, "sd-hdd": {"d3"}}
+	if !reflect.DeepEqual(usage.vms, wantVMs) {
+		t.Errorf("vms = %v, want %v", usage.vms, wantVMs)
+	}
+	if !reflect.DeepEqual(usage.disks, wantDisks) {
+		t.Errorf("disks = %v, want %v", usage.disks, wantDisks)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+// ID of the Blank template, virtual machines created from scratch are based on it.
+const blankTemplateID = "00000000-0000-0000-0000-000000000000"
+
+// States of the template a virtual machine is created from.
+const (
+	templateLatest   = "latest"   // The latest version of the template chain.
+	templateOutdated = "outdated" // There is a newer version in the template chain.
+	templateDeleted  = "deleted"  // The template isn't found on the engine.
+)
+
+// Templates of an engine with their version chains.
+type templateIndex struct {
+	templates map[string]Template
+	latest    map[string]int // Base template ID to the latest version number of the chain.
+	vms       map[string]int // Template ID to the count of VMs created from it.
+}
+
+// func newTemplateIndex - index templates by ID and find the latest version of every chain
+func newTemplateIndex(templates []Template) templateIndex {
+	ti := templateIndex{
+		templates: make(map[string]Template, len(templates)),
+		latest:    make(map[string]int),
+		vms:       make(map[string]int),
+	}
+	for _, t := range templates {
+		ti.templates[t.ID] = t
+		if base := baseTemplateID(t); t.Version.VersionNumber > ti.latest[base] {
+			ti.latest[base] = t.Version.VersionNumber
+		}
+	}
+	return ti
+}
+
+// func baseTemplateID - ID of the base template of the version chain, the template itself for a base version
+func baseTemplateID(t Template) string {
+	if t.Version.BaseTemplate != nil && t.Version.BaseTemplate.ID != "" {
+		return t.Version.BaseTemplate.ID
+	}
+	return t.ID
+}
+
+// func vmTemplateID - ID of the template the VM is created from, empty for a VM created from scratch.
+//
+// Cloned VMs and VMs whose template is removed are based on the Blank template,
+// the template they are created from is referenced as the original template then.
+func vmTemplateID(v Vm) string {
+	if id := templateID(v.Template); id != "" {
+		return id
+	}
+	return templateID(v.OriginalTemplate)
+}
+
+// func vmTemplate - template of the VM, its version and state
+func (ti templateIndex) vmTemplate(v Vm) (name string, version int, state string) {
+	id := vmTemplateID(v)
+	if id == "" {
+		return "", 0, ""
+	}
+	t, ok := ti.templates[id]
+	if !ok {
+		return id, 0, templateDeleted
+	}
+	if t.Version.VersionNumber < ti.latest[baseTemplateID(t)] {
+		return t.Name, t.Version.VersionNumber, templateOutdated
+	}
+	return t.Name, t.Version.VersionNumber, templateLatest
+}
+
+// func templateID - ID of the referenced template, empty for no or the Blank template
+func templateID(t *Template) string {
+	if t == nil || t.ID == blankTemplateID {
+		return ""
+	}
+	return t.ID
+}
+
+// func linkVms - set template of VMs and count VMs per template, vmsStats are in the order of vms
+func (ti templateIndex) linkVms(vmsStats []vmStats, vms []Vm) {
+	for i, v := range vms {
+		vmsStats[i].Template, vmsStats[i].TemplateVersion, vmsStats[i].TemplateState = ti.vmTemplate(v)
+		if id := vmTemplateID(v); id != "" {
+			ti.vms[id]++
+		}
+	}
+}
+
+// func composeTemplateStats - compose stats of templates, the Blank template is skipped.
+// Templates must be linked to VMs already, see linkVms.
+func composeTemplateStats(templates []Template, disksForTemplates []vmDisks, diskList []Disk, ti templateIndex, p placement) []templateStats {
+	disksMap := diskMap(diskList)
+	templatesDisksMap := vmDisksMap(disksForTemplates)
+	var templatesStats = make([]templateStats, 0, len(templates))
+	for _, t := range templates {
+		if t.ID == blankTemplateID {
+			continue
+		}
+		stats := templateStats{
+			ID:            t.ID,
+			Name:          t.Name,
+			Description:   t.Description,
+			Status:        t.Status,
+			CreationTime:  t.CreationTime,
+			VersionName:   t.Version.VersionName,
+			VersionNumber: t.Version.VersionNumber,
+			LatestVersion: ti.latest[baseTemplateID(t)],
+			VmsCount:      ti.vms[t.ID],
+		}
+		stats.Cluster, _ = p.clusterNames(t.Cluster.ID)
+		stats.BaseTemplate = ti.templates[baseTemplateID(t)].Name
+		stats.Outdated = stats.VersionNumber < stats.LatestVersion
+		disks, _ := attachedDisks(templatesDisksMap[t.ID], disksMap, sharedDisks{})
+		stats.ProvisionedSize, stats.ActualSize, _ = diskSizes(disks)
+		stats.DisksCount = len(templatesDisksMap[t.ID])
+		templatesStats = append(templatesStats, stats)
+	}
+	return templatesStats
+}
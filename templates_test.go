@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTemplateLineage(t *testing.T) {
+	base := &Template{ID: "t1"}
+	templates := []Template{
+		{ID: blankTemplateID, Name: "Blank"},
+		{ID: "t1", Name: "rhel8", Version: TemplateVersion{BaseTemplate: base, VersionNumber: 1}},
+		{ID: "t2", Name: "rhel8", Version: TemplateVersion{BaseTemplate: base, VersionNumber: 2, VersionName: "patched"}},
+		{ID: "t3", Name: "win2019", Version: TemplateVersion{VersionNumber: 1}},
+	}
+	vms := []Vm{
+		{ID: "vm1", Template: &Template{ID: "t1"}},
+		{ID: "vm2", Template: &Template{ID: "t2"}},
+		{ID: "vm3", Template: &Template{ID: blankTemplateID}, OriginalTemplate: &Template{ID: "t3"}},
+		{ID: "vm4", Template: &Template{ID: blankTemplateID}, OriginalTemplate: &Template{ID: "t9"}},
+		{ID: "vm5", Template: &Template{ID: blankTemplateID}, OriginalTemplate: &Template{ID: blankTemplateID}},
+		{ID: "vm6"},
+	}
+	tests := []struct {
+		template string
+		version  int
+		state    string
+	}{
+		{"rhel8", 1, templateOutdated},
+		{"rhel8", 2, templateLatest},
+		{"win2019", 1, templateLatest},
+		{"t9", 0, templateDeleted},
+		{"", 0, ""},
+		{"", 0, ""},
+	}
+
+	ti := newTemplateIndex(templates)
+	vmsStats := composeStats(vms, nil, nil, sharedDisks{}, testStorageTiers(t), time.Now())
+	ti.linkVms(vmsStats, vms)
+	for i, tt := range tests {
+		got := vmsStats[i]
+		if got.Template != tt.template || got.TemplateVersion != tt.version || got.TemplateState != tt.state {
+			t.Errorf("%s: template = %q v%d %q, want %q v%d %q", got.ID, got.Template, got.TemplateVersion, got.TemplateState, tt.template, tt.version, tt.state)
+		}
+	}
+
+	disks := []Disk{{ID: "d1", ProvisionedSize: 100, ActualSize: 10}}
+	disksForTemplates := []vmDisks{{vmID: "t2", diskAttachments: []DiskAttachment{{Disk: Disk{ID: "d1"}}}}}
+	templatesStats := composeTemplateStats(templates, disksForTemplates, disks, ti, newPlacement(nil, nil, nil))
+	if len(templatesStats) != 3 {
+		t.Fatalf("composeTemplateStats() returned %d stats, want 3 without Blank", len(templatesStats))
+	}
+	if got := templatesStats[0]; !got.Outdated || got.LatestVersion != 2 || got.BaseTemplate != "rhel8" || got.VmsCount != 1 {
+		t.Errorf("t1 = %+v, want outdated version of rhel8 with 1 VM", got)
+	}
+	if got := templatesStats[1]; got.Outdated || got.ProvisionedSize != 100 || got.ActualSize != 10 || got.DisksCount != 1 {
+		t.Errorf("t2 = %+v, want latest version with one disk", got)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tier of disks not matched by any rule, if the default tier isn't configured.
+const defaultTier = "hdd"
+
+// Rules applied if none are configured, disks on storage domains named *ssd* are on ssd tier.
+var defaultTierRules = []tierRule{
+	{Tier: "ssd", Name: "(?i)ssd"},
+}
+
+// Storage tiers configuration, see tierClassifier.
+type tiersConfig struct {
+	Default string     `yaml:"default" toml:"default"` // Tier of disks not matched by any rule.
+	Rules   []tierRule `yaml:"rules" toml:"rules"`     // Rules in order of priority, the first matched rule wins.
+}
+
+// Rule mapping storage domains to a tier. All set criteria must match.
+type tierRule struct {
+	Tier string      `yaml:"tier" toml:"tier"` // Tier name, e.g. nvme, ssd, hdd, archive.
+	ID   string      `yaml:"id" toml:"id"`     // Storage domain ID.
+	Name string      `yaml:"name" toml:"name"` // Regular expression matched against storage domain name.
+	Type StorageType `yaml:"type" toml:"type"` // Storage type, e.g. nfs, iscsi, fcp, glusterfs.
+	// Tag of storage domain. oVirt doesn't tag storage domains, a tag is a word
+	// in the storage domain comment, words are separated by spaces and commas.
+	Tag string `yaml:"tag" toml:"tag"`
+}
+
+type compiledTierRule struct {
+	tierRule
+	name *regexp.Regexp
+}
+
+// Classifier of storage domains into tiers by rules.
+type tierClassifier struct {
+	defaultTier string
+	rules       []compiledTierRule
+}
+
+// func newTierClassifier - compile the rules of tiers configuration
+func newTierClassifier(cfg tiersConfig) (*tierClassifier, error) {
+	c := &tierClassifier{defaultTier: cfg.Default}
+	if c.defaultTier == "" {
+		c.defaultTier = defaultTier
+	}
+	rules := cfg.Rules
+	if len(rules) == 0 {
+		rules = defaultTierRules
+	}
+	for i, rule := range rules {
+		if rule.Tier == "" {
+			return nil, fmt.Errorf("storage tier rule %d: tier is not set", i+1)
+		}
+		if rule.ID == "" && rule.Name == "" && rule.Type == "" && rule.Tag == "" {
+			return nil, fmt.Errorf("storage tier rule %d (%s): set at least one of id, name, type or tag", i+1, rule.Tier)
+		}
+		compiled := compiledTierRule{tierRule: rule}
+		if rule.Name != "" {
+			re, err := regexp.Compile(rule.Name)
+			if err != nil {
+				return nil, fmt.Errorf("storage tier rule %d (%s): %w", i+1, rule.Tier, err)
+			}
+			compiled.name = re
+		}
+		c.rules = append(c.rules, compiled)
+	}
+	return c, nil
+}
+
+// func classify - tier of the storage domain, the default tier if no rule matches
+func (c *tierClassifier) classify(domain StorageDomain) string {
+	for _, rule := range c.rules {
+		if rule.matches(domain) {
+			return rule.Tier
+		}
+	}
+	return c.defaultTier
+}
+
+func (r compiledTierRule) matches(domain StorageDomain) bool {
+	if r.ID != "" && r.ID != domain.ID {
+		return false
+	}
+	if r.name != nil && !r.name.MatchString(domain.Name) {
+		return false
+	}
+	if r.Type != "" && !strings.EqualFold(string(r.Type), string(domain.Storage.Type)) {
+		return false
+	}
+	if r.Tag != "" && !hasTag(domain.Comment, r.Tag) {
+		return false
+	}
+	return true
+}
+
+// func hasTag - helps to determine is the tag a word of the comment or not
+func hasTag(comment string, tag string) bool {
+	words := strings.FieldsFunc(comment, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	for _, word := range words {
+		if strings.EqualFold(word, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tiers of storage domains of an engine.
+type storageTiers struct {
+	classifier *tierClassifier
+	domains    map[string]string // Storage domain ID to tier.
+}
+
+// func newStorageTiers - classify storage domains of an engine
+func newStorageTiers(classifier *tierClassifier, domains []StorageDomain) storageTiers {
+	tiers := storageTiers{
+		classifier: classifier,
+		domains:    make(map[string]string, len(domains)),
+	}
+	for _, domain := range domains {
+		tiers.domains[domain.ID] = classifier.classify(domain)
+	}
+	return tiers
+}
+
+// func diskTier - tier of the storage domain the disk resides on.
+// Direct LUN disks don't reside on a storage domain, they are classified by the LUN storage type.
+func (t storageTiers) diskTier(disk Disk) string {
+	for _, domain := range disk.StorageDomains {
+		if tier, ok := t.domains[domain.ID]; ok {
+			return tier
+		}
+	}
+	return t.classifier.classify(StorageDomain{Storage: disk.LunStorage})
+}
+
+// func tierSizes - provisioned size of disks grouped by tier
+func tierSizes(disks []Disk, tiers storageTiers) map[string]int {
+	if len(disks) == 0 {
+		return nil
+	}
+	sizes := make(map[string]int)
+	for _, disk := range disks {
+		sizes[tiers.diskTier(disk)] += disk.ProvisionedSize
+	}
+	return sizes
+}
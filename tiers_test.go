@@ -0,0 +1,84 @@
+package main
+
+import "testing"
+
+func TestTierClassifier(t *testing.T) {
+	classifier, err := newTierClassifier(tiersConfig{
+		Default: "unclassified",
+		Rules: []tierRule{
+			{Tier: "nvme", ID: "sd-1"},
+			{Tier: "archive", Type: "nfs", Name: "^backup-"},
+			{Tier: "ssd", Tag: "ssd"},
+			{Tier: "hdd", Type: "glusterfs"},
+			{Tier: "hdd", Name: "(?i)sata"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		domain StorageDomain
+		want   string
+	}{
+		{"by id", StorageDomain{ID: "sd-1", Name: "backup-01", Storage: HostStorage{Type: "nfs"}}, "nvme"},
+		{"by name and type", StorageDomain{ID: "sd-2", Name: "backup-01", Storage: HostStorage{Type: "nfs"}}, "archive"},
+		{"name without type", StorageDomain{ID: "sd-3", Name: "backup-02", Storage: HostStorage{Type: "iscsi"}}, "unclassified"},
+		{"by tag", StorageDomain{ID: "sd-4", Name: "data", Comment: "fast, SSD"}, "ssd"},
+		{"tag is a word", StorageDomain{ID: "sd-5", Name: "data", Comment: "nossd"}, "unclassified"},
+		{"by type", StorageDomain{ID: "sd-6", Storage: HostStorage{Type: "glusterfs"}}, "hdd"},
+		{"by name", StorageDomain{ID: "sd-7", Name: "FC-SATA-02"}, "hdd"},
+		{"default", StorageDomain{ID: "sd-8", Name: "data"}, "unclassified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.classify(tt.domain); got != tt.want {
+				t.Errorf("classify(%+v) = %s, want %s", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTierClassifierErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule tierRule
+	}{
+		{"no tier", tierRule{Name: "ssd"}},
+		{"no criteria", tierRule{Tier: "ssd"}},
+		{"bad regexp", tierRule{Tier: "ssd", Name: "("}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newTierClassifier(tiersConfig{Rules: []tierRule{tt.rule}}); err == nil {
+				t.Errorf("newTierClassifier(%+v) returned no error", tt.rule)
+			}
+		})
+	}
+}
+
+func TestDiskTier(t *testing.T) {
+	classifier, err := newTierClassifier(tiersConfig{Rules: []tierRule{{Tier: "ssd", Name: "ssd"}, {Tier: "san", Type: "fcp"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tiers := newStorageTiers(classifier, []StorageDomain{{ID: "sd-1", Name: "ssd-01"}})
+
+	tests := []struct {
+		name string
+		disk Disk
+		want string
+	}{
+		{"image disk", Disk{StorageDomains: []StorageDomain{{ID: "sd-1"}}}, "ssd"},
+		{"unknown domain", Disk{StorageDomains: []StorageDomain{{ID: "sd-2"}}}, defaultTier},
+		{"direct lun", Disk{StorageType: "lun", LunStorage: HostStorage{Type: "fcp"}}, "san"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tiers.diskTier(tt.disk); got != tt.want {
+				t.Errorf("diskTier() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
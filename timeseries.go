@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timeout of sending time series to InfluxDB or Graphite.
+const sendTimeout = 30 * time.Second
+
+// A point of a time series: the measurement, tags and fields.
+// In Graphite format every numeric field is a series named measurement.field, tagged with the tags.
+//
+// Tags identify the series and stay the same for the life of the object, values which change, like the status,
+// are fields: a tag value change would start a new series. The host of a VM point is the exception: it changes
+// when the VM migrates, so a migrated VM starts a new series on its new host.
+type point struct {
+	measurement string
+	tags        []string // Name and value pairs, empty values are dropped on write.
+	fields      []field
+}
+
+type field struct {
+	name  string
+	value any // int, float64 or string, string fields are written in InfluxDB format only.
+}
+
+// func inventoryPoints - points of VMs, VM disks per tier, hosts and storage domains
+func inventoryPoints(inv *inventory) []point {
+	var points []point
+	for _, vm := range inv.VMs {
+		tags := []string{"engine", vm.Engine, "data_center", vm.DataCenter, "cluster", vm.Cluster, "host", vm.Host, "vm", vm.Name}
+		points = append(points, point{
+			measurement: "ovirt_vm",
+			tags:        tags,
+			fields: []field{
+				{"status", string(vm.Status)},
+				{"cpu", vm.Cpu},
+				{"memory", vm.Memory},
+				{"provisioned_size", vm.ProvisionedSize},
+				{"actual_size", vm.ActualSize},
+				{"total_size", vm.TotalSize},
+				{"snapshots_count", vm.SnapshotsCount},
+				{"snapshots_size", vm.SnapshotsSize},
+				{"uptime", int(vm.Uptime)},
+				{"age_days", vm.AgeDays},
+			},
+		})
+		for _, tier := range sortedKeys(vm.DiskSizeByTier) {
+			points = append(points, point{
+				measurement: "ovirt_vm_disk",
+				tags:        append(tags[:len(tags):len(tags)], "tier", tier),
+				fields:      []field{{"provisioned_size", vm.DiskSizeByTier[tier]}},
+			})
+		}
+	}
+	for _, h := range inv.Hosts {
+		points = append(points, point{
+			measurement: "ovirt_host",
+			tags:        []string{"engine", h.Engine, "data_center", h.DataCenter, "cluster", h.Cluster, "host", h.Name},
+			fields: []field{
+				{"status", string(h.Status)},
+				{"cpu_threads", h.CpuThreads},
+				{"memory", h.Memory},
+				{"vms_active", h.VmsActive},
+				{"vms_migrating", h.VmsMigrating},
+				{"vms_total", h.VmsTotal},
+			},
+		})
+	}
+	for _, d := range inv.StorageDomains {
+		points = append(points, point{
+			measurement: "ovirt_storage_domain",
+			tags:        []string{"engine", d.Engine, "storage_domain", d.Name, "type", string(d.Type), "tier", d.Tier},
+			fields: []field{
+				{"available", d.Available},
+				{"used", d.Used},
+				{"committed", d.Committed},
+				{"total", d.Total},
+				{"used_percent", d.UsedPercent},
+				{"overcommit_ratio", d.OvercommitRatio},
+			},
+		})
+	}
+	return points
+}
+
+// func writeInflux - write points in InfluxDB line protocol, tags are sorted by name, the timestamp is in nanoseconds
+func writeInflux(w io.Writer, points []point, now time.Time) error {
+	ts := strconv.FormatInt(now.UnixNano(), 10)
+	var line strings.Builder
+	for _, p := range points {
+		line.Reset()
+		line.WriteString(influxMeasurementEscaper.Replace(p.measurement))
+		for _, t := range sortedTags(p.tags) {
+			line.WriteString("," + influxTagEscaper.Replace(t[0]) + "=" + influxTagEscaper.Replace(t[1]))
+		}
+		for i, f := range p.fields {
+			sep := ","
+			if i == 0 {
+				sep = " "
+			}
+			line.WriteString(sep + influxTagEscaper.Replace(f.name) + "=" + influxValue(f.value))
+		}
+		line.WriteString(" " + ts + "\n")
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Escaping of InfluxDB line protocol.
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+	influxStringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// func influxValue - field value, integers with the "i" suffix, strings in double quotes
+func influxValue(v any) string {
+	switch v := v.(type) {
+	case int:
+		return strconv.Itoa(v) + "i"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return `"` + influxStringEscaper.Replace(v) + `"`
+	}
+	return fmt.Sprint(v)
+}
+
+// func writeGraphite - write points in Graphite plaintext protocol with tags, a line per numeric field,
+// the timestamp is in seconds. Graphite values are numbers only, string fields are skipped.
+//
+// Series are named measurement.field and tagged as in Graphite 1.1: "ovirt_vm.memory;engine=e1;vm=web01 4294967296 1678449600".
+func writeGraphite(w io.Writer, points []point, now time.Time) error {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	for _, p := range points {
+		var tags strings.Builder
+		for _, t := range sortedTags(p.tags) {
+			tags.WriteString(";" + graphiteEscaper.Replace(t[0]) + "=" + graphiteEscaper.Replace(t[1]))
+		}
+		for _, f := range p.fields {
+			if _, ok := f.value.(string); ok {
+				continue
+			}
+			line := graphiteEscaper.Replace(p.measurement+"."+f.name) + tags.String() + " " + graphiteValue(f.value) + " " + ts + "\n"
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Characters not allowed in Graphite names and tags are replaced with "_".
+var graphiteEscaper = strings.NewReplacer(" ", "_", ";", "_", "~", "_", "!", "_", "^", "_", "=", "_", "\n", "_")
+
+func graphiteValue(v any) string {
+	if v, ok := v.(float64); ok {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// func sortedTags - tag pairs with non-empty values sorted by name
+func sortedTags(tags []string) [][2]string {
+	pairs := make([][2]string, 0, len(tags)/2)
+	for i := 0; i+1 < len(tags); i += 2 {
+		if tags[i+1] != "" {
+			pairs = append(pairs, [2]string{tags[i], tags[i+1]})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// func sendOutput - send the inventory to the URL of the output: line protocol to the InfluxDB write
+// endpoint over HTTP, Graphite plaintext to the Graphite address over TCP. An https endpoint is verified
+// with the CA of the output if it is set, with the system CAs otherwise.
+func sendOutput(c outputConfig, inv *inventory) error {
+	var body bytes.Buffer
+	if err := writeInventory(&body, c, inv); err != nil {
+		return err
+	}
+	u, err := neturl.Parse(c.URL)
+	if err != nil {
+		return err
+	}
+	if c.Format == formatGraphite {
+		conn, err := net.DialTimeout("tcp", u.Host, sendTimeout)
+		if err != nil {
+			return err
+		}
+		if err := conn.SetDeadline(time.Now().Add(sendTimeout)); err != nil {
+			conn.Close()
+			return fmt.Errorf("graphite %s: %w", u.Host, err)
+		}
+		if _, err := body.WriteTo(conn); err != nil {
+			conn.Close()
+			return fmt.Errorf("graphite %s: %w", u.Host, err)
+		}
+		return conn.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+	client := &http.Client{Timeout: sendTimeout}
+	if c.CAFile != "" {
+		transport, err := tlsTranstort(c.CAFile)
+		if err != nil {
+			return err
+		}
+		client.Transport = transport
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("influx write %s: %s: %s", u.Redacted(), resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
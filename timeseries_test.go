@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPointsInventory() *inventory {
+	return &inventory{
+		VMs: []vmStats{{Engine: "engine1", Cluster: "prod", Host: "host 01", Name: "web,01", Status: "up", Cpu: 2, Memory: 4 << 30,
+			DiskSizeByTier: map[string]int{"ssd": 10}}},
+		StorageDomains: []storageDomainStats{{Engine: "engine1", Name: "FC-SSD-01", Type: "data", Tier: "ssd", Total: 100, UsedPercent: 12.5}},
+	}
+}
+
+func TestWriteInflux(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	if err := writeInflux(&b, inventoryPoints(testPointsInventory()), now); err != nil {
+		t.Fatal(err)
+	}
+	want := `ovirt_vm,cluster=prod,engine=engine1,host=host\ 01,vm=web\,01 status="up",cpu=2i,memory=4294967296i,provisioned_size=0i,` +
+		`actual_size=0i,total_size=0i,snapshots_count=0i,snapshots_size=0i,uptime=0i,age_days=0i 1678449600000000000` + "\n" +
+		`ovirt_vm_disk,cluster=prod,engine=engine1,host=host\ 01,tier=ssd,vm=web\,01 provisioned_size=10i 1678449600000000000` + "\n" +
+		`ovirt_storage_domain,engine=engine1,storage_domain=FC-SSD-01,tier=ssd,type=data available=0i,used=0i,committed=0i,total=100i,` +
+		`used_percent=12.5,overcommit_ratio=0 1678449600000000000` + "\n"
+	if b.String() != want {
+		t.Errorf("writeInflux() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteGraphite(t *testing.T) {
+	now := time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	if err := writeGraphite(&b, inventoryPoints(testPointsInventory()), now); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(b.String(), "\n")
+	for _, want := range []string{
+		"ovirt_vm.memory;cluster=prod;engine=engine1;host=host_01;vm=web,01 4294967296 1678449600",
+		"ovirt_vm_disk.provisioned_size;cluster=prod;engine=engine1;host=host_01;tier=ssd;vm=web,01 10 1678449600",
+		"ovirt_storage_domain.used_percent;engine=engine1;storage_domain=FC-SSD-01;tier=ssd;type=data 12.5 1678449600",
+	} {
+		if !containsLine(lines, want) {
+			t.Errorf("writeGraphite() has no line %q", want)
+		}
+	}
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSendOutputInflux(t *testing.T) {
+	var auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := outputConfig{Format: formatInflux, URL: server.URL + "/api/v2/write?bucket=ovirt", Token: "secret"}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutput(c, testPointsInventory()); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Token secret" {
+		t.Errorf("Authorization = %q, want %q", auth, "Token secret")
+	}
+	if !strings.HasPrefix(body, "ovirt_vm,") || strings.Count(body, "\n") != 3 {
+		t.Errorf("body = %q, want 3 points", body)
+	}
+}
+
+func TestSendOutputInfluxCA(t *testing.T) {
+	received := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := outputConfig{Format: formatInflux, URL: server.URL + "/api/v2/write?bucket=ovirt"}
+	if err := sendOutput(c, testPointsInventory()); err == nil {
+		t.Error("sendOutput() without the CA file succeeded, want certificate error")
+	}
+	c.CAFile = caFile
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendOutput(c, testPointsInventory()); err != nil {
+		t.Fatalf("sendOutput() error = %v", err)
+	}
+	if !received {
+		t.Error("InfluxDB received no points")
+	}
+}
+
+func TestSendOutputGraphite(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	received := make(chan int)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(received)
+			return
+		}
+		defer conn.Close()
+		lines := 0
+		for s := bufio.NewScanner(conn); s.Scan(); {
+			lines++
+		}
+		received <- lines
+	}()
+
+	c := outputConfig{Format: formatGraphite, URL: "tcp://" + ln.Addr().String()}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutput(c, testPointsInventory()); err != nil {
+		t.Fatal(err)
+	}
+	// 9 numeric fields of the VM, 1 of the VM disk and 6 of the storage domain, the status is not sent.
+	if lines := <-received; lines != 16 {
+		t.Errorf("graphite received %d lines, want 16", lines)
+	}
+}
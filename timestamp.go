@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date of oVirt API.
+//
+// When requesting the JSON representation the engine sends dates as an integer containing the number
+// of milliseconds since Jan 1st 1970, also know as epoch time [https://en.wikipedia.org/wiki/Unix_time].
+// Timestamp is rendered back to JSON as ISO-8601 date, the zero Timestamp is rendered as null.
+type Timestamp struct {
+	time.Time
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*t = Timestamp{}
+		return nil
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("timestamp %s is not epoch time in milliseconds: %w", data, err)
+	}
+	t.Time = time.UnixMilli(ms).UTC()
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339))
+}
+
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampRoundTrip(t *testing.T) {
+	type doc struct {
+		Time Timestamp `json:"time"`
+	}
+	tests := []struct {
+		name string
+		data string
+		want time.Time
+		json string
+	}{
+		{"epoch ms", `{"time":1676626200000}`, time.Date(2023, 2, 17, 9, 30, 0, 0, time.UTC), `{"time":"2023-02-17T09:30:00Z"}`},
+		{"string encoded", `{"time":"1676626200000"}`, time.Date(2023, 2, 17, 9, 30, 0, 0, time.UTC), `{"time":"2023-02-17T09:30:00Z"}`},
+		{"milliseconds", `{"time":1676626200123}`, time.Date(2023, 2, 17, 9, 30, 0, 123e6, time.UTC), `{"time":"2023-02-17T09:30:00Z"}`},
+		{"null", `{"time":null}`, time.Time{}, `{"time":null}`},
+		{"empty string", `{"time":""}`, time.Time{}, `{"time":null}`},
+		{"absent", `{}`, time.Time{}, `{"time":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d doc
+			if err := json.Unmarshal([]byte(tt.data), &d); err != nil {
+				t.Fatal(err)
+			}
+			if !d.Time.Equal(tt.want) {
+				t.Errorf("UnmarshalJSON() = %v, want %v", d.Time, tt.want)
+			}
+			// The engine sends string encoded numbers, the oVirt decoder passes them to UnmarshalJSON as is.
+			var o doc
+			if err := unmarshalOvirt([]byte(tt.data), &o); err != nil {
+				t.Fatal(err)
+			}
+			if !o.Time.Equal(tt.want) {
+				t.Errorf("unmarshalOvirt() = %v, want %v", o.Time, tt.want)
+			}
+			data, err := json.Marshal(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("MarshalJSON() = %s, want %s", data, tt.json)
+			}
+		})
+	}
+}
+
+func TestTimestampUnmarshalError(t *testing.T) {
+	var ts Timestamp
+	if err := ts.UnmarshalJSON([]byte(`"2023-02-17"`)); err == nil {
+		t.Error("UnmarshalJSON() of a date string succeeded, want error")
+	}
+}
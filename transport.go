@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status codes of responses worth to retry, the engine or the proxy in front of it is busy.
+var retryStatuses = map[int]bool{
+	http.StatusTooManyRequests:    true,
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// Retry of idempotent requests with exponential backoff and full jitter.
+//
+// The delay before retry n is random in [0, min(MaxBackoff, InitialBackoff * 2^(n-1))],
+// the delay from Retry-After header of the response is used instead if it is set, capped by MaxBackoff.
+// Every attempt, reading of the response body included, is limited by the timeout on its own,
+// a timed out attempt is retried. Delays between attempts are limited by the request context only.
+type retryTransport struct {
+	base    http.RoundTripper
+	cfg     retryConfig
+	timeout time.Duration // Timeout of a single attempt, 0 is unlimited.
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newRetryTransport(base http.RoundTripper, cfg retryConfig, timeout time.Duration) *retryTransport {
+	return &retryTransport{
+		base:    base,
+		cfg:     cfg,
+		timeout: timeout,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.attempt(req)
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(req)
+		if attempt >= t.cfg.Attempts || !retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt)
+		reason := "transport error"
+		if err != nil {
+			reason = err.Error()
+		}
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				// The wait is not limited by the timeout of an attempt, an hour of Retry-After would stall the run.
+				wait = d
+				if wait > t.cfg.MaxBackoff {
+					wait = t.cfg.MaxBackoff
+				}
+			}
+			reason = resp.Status
+			// Drain the body to reuse the connection.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		log.Printf("%s %s: %s, attempt %d of %d, retry in %v", req.Method, req.URL, reason, attempt, t.cfg.Attempts, wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// func attempt - send the request once, limited by the timeout until the response body is closed
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// Body of the response which releases the context of the attempt on close.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	ceiling := t.cfg.MaxBackoff
+	if shift := attempt - 1; shift < 32 {
+		if d := t.cfg.InitialBackoff << shift; d > 0 && d < ceiling {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Duration(t.rand.Int63n(int64(ceiling) + 1))
+}
+
+// func retryable - helps to determine is the failed request worth to retry or not
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, errCircuitOpen)
+	}
+	return retryStatuses[resp.StatusCode]
+}
+
+// func retryAfter - parse Retry-After header, it is either delay in seconds or HTTP date
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Circuit breaker of a single engine.
+//
+// After Threshold failures in a row the circuit is open and requests fail immediately with errCircuitOpen.
+// Once Cooldown passed the circuit is half-open: a single probe request is let through, the others still fail
+// until the probe completes. Success of the probe closes the circuit, failure opens it again.
+type breakerTransport struct {
+	base      http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool // The probe request of the half-open circuit is in flight.
+}
+
+func newBreakerTransport(base http.RoundTripper, cfg retryConfig) *breakerTransport {
+	return &breakerTransport{
+		base:      base,
+		threshold: cfg.BreakerThreshold,
+		cooldown:  cfg.BreakerCooldown,
+	}
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.threshold <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	t.mu.Lock()
+	probe := false
+	if t.failures >= t.threshold {
+		if t.probing || time.Since(t.openedAt) < t.cooldown {
+			t.mu.Unlock()
+			return nil, errCircuitOpen
+		}
+		t.probing, probe = true, true
+	}
+	t.mu.Unlock()
+
+	resp, err := t.base.RoundTrip(req)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if probe {
+		t.probing = false
+	}
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		t.failures++
+		if t.failures >= t.threshold {
+			if t.failures == t.threshold {
+				log.Printf("%s: %d failed requests in a row, circuit is open for %v", req.URL.Host, t.failures, t.cooldown)
+			}
+			t.openedAt = time.Now()
+		}
+	} else {
+		t.failures = 0
+	}
+	return resp, err
+}
+
+// Rate limiter of requests to a single engine, requests are spread evenly at the rate per second.
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest moment of the next request.
+}
+
+// func newRateLimitTransport - limit requests to rate per second, rate <= 0 disables the limit
+func newRateLimitTransport(base http.RoundTripper, rate float64) http.RoundTripper {
+	if rate <= 0 {
+		return base
+	}
+	return &rateLimitTransport{
+		base:     base,
+		interval: time.Duration(float64(time.Second) / rate),
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	wait := t.next.Sub(now)
+	t.next = t.next.Add(t.interval)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	return t.base.RoundTrip(req)
+}
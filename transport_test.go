@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// func scriptedServer - test server responding with the statuses in order, the last one repeats
+func scriptedServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&hits, 1)) - 1
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[i])
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func testRetryConfig() retryConfig {
+	return retryConfig{Attempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		want     int
+		wantHits int32
+	}{
+		{"retry on 502", http.MethodGet, []int{502, 200}, 200, 2},
+		{"retry on 503", http.MethodGet, []int{503, 503, 200}, 200, 3},
+		{"retry on 429", http.MethodGet, []int{429, 200}, 200, 2},
+		{"attempts exhausted", http.MethodGet, []int{503}, 503, 4},
+		{"no retry on 404", http.MethodGet, []int{404, 200}, 404, 1},
+		{"no retry on 400", http.MethodGet, []int{400, 200}, 400, 1},
+		{"no retry on 500", http.MethodGet, []int{500, 200}, 500, 1},
+		{"no retry of POST", http.MethodPost, []int{503, 200}, 503, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, hits := scriptedServer(t, tt.statuses...)
+			client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, testRetryConfig(), 0)}
+			req, _ := http.NewRequest(tt.method, server.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want || *hits != tt.wantHits {
+				t.Errorf("status %d after %d requests, want %d after %d", resp.StatusCode, *hits, tt.want, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		maxBackoff time.Duration
+	}{
+		// The backoff would take an hour, Retry-After overrides it.
+		{"seconds", "0", time.Hour},
+		{"HTTP date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), time.Hour},
+		// Retry-After asks for an hour, the wait is capped by the max backoff.
+		{"longer than max backoff", "3600", 10 * time.Millisecond},
+		{"HTTP date later than max backoff", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 10 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&hits, 1) == 1 {
+					w.Header().Set("Retry-After", tt.value)
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+			cfg := retryConfig{Attempts: 2, InitialBackoff: time.Hour, MaxBackoff: tt.maxBackoff}
+			client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, cfg, 0)}
+			done := make(chan error, 1)
+			go func() {
+				resp, err := client.Get(server.URL)
+				if err == nil {
+					resp.Body.Close()
+				}
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("the retry didn't happen in time")
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+		ok       bool
+	}{
+		{"120", 120 * time.Second, 120 * time.Second, true},
+		{time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat), 28 * time.Second, 30 * time.Second, true},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0, true},
+		{"", 0, 0, false},
+		{"soon", 0, 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		d, ok := retryAfter(resp)
+		if ok != tt.ok || d < tt.min || d > tt.max {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v..%v, %v", tt.value, d, ok, tt.min, tt.max, tt.ok)
+		}
+	}
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	cfg := retryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	rt := newRetryTransport(nil, cfg, 0)
+	ceilings := map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 40: time.Second}
+	for attempt, ceiling := range ceilings {
+		for i := 0; i < 1000; i++ {
+			if d := rt.backoff(attempt); d < 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want at most %v", attempt, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestRetryTransportTimeoutPerAttempt(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newRetryTransport(http.DefaultTransport, testRetryConfig(), 100*time.Millisecond)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || string(body) != "ok" || hits != 2 {
+		t.Errorf("body %q, error %v after %d requests, want ok after the timed out attempt is retried", body, err, hits)
+	}
+}
+
+func TestBreakerTransport(t *testing.T) {
+	server, hits := scriptedServer(t, 500, 500, 200)
+	cfg := retryConfig{Attempts: 4, BreakerThreshold: 2, BreakerCooldown: 100 * time.Millisecond}
+	breaker := newBreakerTransport(http.DefaultTransport, cfg)
+	client := &http.Client{Transport: newRetryTransport(breaker, cfg, 0)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// The circuit is open, the request fails without reaching the server and is not retried.
+	if _, err := client.Get(server.URL); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("error = %v with open circuit, want errCircuitOpen", err)
+	}
+	if *hits != 2 {
+		t.Errorf("server got %d requests, want 2", *hits)
+	}
+
+	time.Sleep(cfg.BreakerCooldown)
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request after cooldown: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || breaker.failures != 0 {
+		t.Errorf("status %d, %d failures after cooldown, want closed circuit", resp.StatusCode, breaker.failures)
+	}
+}
+
+func TestBreakerTransportSingleProbe(t *testing.T) {
+	release := make(chan struct{})
+	arrived := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	breaker := newBreakerTransport(http.DefaultTransport, retryConfig{BreakerThreshold: 1, BreakerCooldown: time.Millisecond})
+	breaker.failures, breaker.openedAt = 1, time.Now().Add(-time.Second)
+	client := &http.Client{Transport: breaker}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if resp, err := client.Get(server.URL); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-arrived
+	for i := 0; i < 3; i++ {
+		if _, err := client.Get(server.URL); !errors.Is(err, errCircuitOpen) {
+			t.Errorf("error = %v while the probe is in flight, want errCircuitOpen", err)
+		}
+	}
+	close(release)
+	wg.Wait()
+	if len(arrived) != 0 {
+		t.Errorf("server got %d requests besides the probe", len(arrived))
+	}
+	if breaker.failures != 0 {
+		t.Errorf("%d failures after the successful probe, want 0", breaker.failures)
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	server, hits := scriptedServer(t, 200)
+	client := &http.Client{Transport: newRateLimitTransport(http.DefaultTransport, 20)}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Requests are spread 50ms apart, the first one goes immediately.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || *hits != 3 {
+		t.Errorf("3 requests took %v, want at least 100ms", elapsed)
+	}
+}
+
+func TestRetryableCircuitOpen(t *testing.T) {
+	if retryable(nil, errCircuitOpen) || !retryable(nil, errors.New("connection reset")) {
+		t.Error("retryable() must skip errCircuitOpen and retry other transport errors")
+	}
+	if !retryable(&http.Response{StatusCode: 504, Body: io.NopCloser(strings.NewReader(""))}, nil) {
+		t.Error("retryable() must retry 504")
+	}
+}
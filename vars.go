@@ -1,35 +1,266 @@
 package main
 
-import "time"
-
 type vmDisks struct {
 	vmID            string
 	diskAttachments []DiskAttachment
 }
 
+type vmNics struct {
+	vmID            string
+	nics            []Nic
+	reportedDevices []ReportedDevice
+}
+
+type vmSnapshots struct {
+	vmID      string
+	snapshots []Snapshot
+}
+
 type vmStats struct {
-	Comment string `json:"comment,omitempty"` // Free text containing comments about this object.
-	Cpu     int    `json:"cpu,omitempty"`     // Numder of CPU for the virtual machine.
-	// The virtual machine creation date.
-	// When requesting the JSON representation the engine uses a different, format:
-	// an integer containing the number of seconds since Jan 1st 1970, also know as
-	// epoch time [https://en.wikipedia.org/wiki/Unix_time].
-	CreationTime time.Duration `json:"creation_time,omitempty"`
-	Description  string        `json:"description,omitempty"`   // A human-readable description in plain text.
-	FQDN         string        `json:"fqdn,omitempty"`          // Fully qualified domain name of the virtual machine.
-	ID           string        `json:"id,omitempty"`            // A unique identifier.
-	Memory       int           `json:"memory,omitempty"`        // The virtual machine’s memory, in bytes.
-	Name         string        `json:"name,omitempty"`          // A human-readable name in plain text.
-	OS           string        `json:"os,omitempty"`            // Operating system type installed on the virtual machine. From the field Type from struct OperatingSystem
-	RunOnce      bool          `json:"run_once,omitempty"`      // If true, the virtual machine has been started using the run once command, meaning it’s configuration might differ from the stored one for the purpose of this single run.
-	SerialNumber string        `json:"serial_number,omitempty"` // Virtual machine’s serial number in a cluster. The field Value of struct SerialNumber
-	StartTime    time.Duration `json:"start_time,omitempty"`    // The date in which the virtual machine was started.
-	Status       VmStatus      `json:"status,omitempty"`        // The current status of the virtual machine.
-	StatusDetail string        `json:"status_detail,omitempty"` // Human readable detail of current status.
-	StopReason   string        `json:"stop_reason,omitempty"`   // The reason the virtual machine was stopped.
-	StopTime     time.Duration `json:"stop_time,omitempty"`     // The date in which the virtual machine was stopped.
-
-	HddDiskSize  int `json:"hdd_disk_size,omitempty"`  // The size of all disks attached to vm. Sum of initial_size from Disk struct, in bytes. Group by StorageType (HDD or SSD).
-	SsdDiskSize  int `json:"ssd_disk_size,omitempty"`  // The size of all disks attached to vm. Sum of initial_size from Disk struct, in bytes. Group by StorageType (HDD or SSD).
-	VmDisksCount int `json:"vm_disks_count,omitempty"` // The count of attached virtual disks
+	Engine          string    `json:"engine,omitempty"`           // Name of the oVirt engine the virtual machine is fetched from.
+	DataCenter      string    `json:"data_center,omitempty"`      // Name of the data center of the virtual machine cluster.
+	Cluster         string    `json:"cluster,omitempty"`          // Name of the cluster the virtual machine belongs to.
+	Host            string    `json:"host,omitempty"`             // Name of the host the virtual machine is running on.
+	Template        string    `json:"template,omitempty"`         // Name of the template the virtual machine is created from, its ID if the template is deleted.
+	TemplateVersion int       `json:"template_version,omitempty"` // Version number of the template the virtual machine is created from.
+	TemplateState   string    `json:"template_state,omitempty"`   // State of the template: latest, outdated or deleted, see templateLatest.
+	Comment         string    `json:"comment,omitempty"`          // Free text containing comments about this object.
+	Cpu             int       `json:"cpu,omitempty"`              // Number of virtual CPUs of the virtual machine, sockets × cores × threads.
+	CreationTime    Timestamp `json:"creation_time,omitempty"`    // The virtual machine creation date.
+	Description     string    `json:"description,omitempty"`      // A human-readable description in plain text.
+	FQDN            string    `json:"fqdn,omitempty"`             // Fully qualified domain name of the virtual machine.
+	ID              string    `json:"id,omitempty"`               // A unique identifier.
+	Memory          int       `json:"memory,omitempty"`           // The virtual machine’s memory, in bytes.
+	Name            string    `json:"name,omitempty"`             // A human-readable name in plain text.
+	OS              string    `json:"os,omitempty"`               // Operating system type installed on the virtual machine. From the field Type from struct OperatingSystem
+	RunOnce         bool      `json:"run_once,omitempty"`         // If true, the virtual machine has been started using the run once command, meaning it’s configuration might differ from the stored one for the purpose of this single run.
+	SerialNumber    string    `json:"serial_number,omitempty"`    // Virtual machine’s serial number in a cluster. The field Value of struct SerialNumber
+	StartTime       Timestamp `json:"start_time,omitempty"`       // The date in which the virtual machine was started.
+	Status          VmStatus  `json:"status,omitempty"`           // The current status of the virtual machine.
+	StatusDetail    string    `json:"status_detail,omitempty"`    // Human readable detail of current status.
+	StopReason      string    `json:"stop_reason,omitempty"`      // The reason the virtual machine was stopped.
+	StopTime        Timestamp `json:"stop_time,omitempty"`        // The date in which the virtual machine was stopped.
+	AgeDays         int       `json:"age_days,omitempty"`         // Days passed since the virtual machine creation.
+	Uptime          int64     `json:"uptime,omitempty"`           // Seconds passed since the virtual machine start, if it is running.
+
+	ProvisionedSize    int            `json:"provisioned_size,omitempty"`     // The virtual size of all disks attached to vm, in bytes. Sum of provisioned_size from Disk struct.
+	ActualSize         int            `json:"actual_size,omitempty"`          // The actual size of all disks attached to vm, in bytes. Sum of actual_size from Disk struct.
+	TotalSize          int            `json:"total_size,omitempty"`           // The size of all disks attached to vm including their snapshots, in bytes. Sum of total_size from Disk struct.
+	ThinRatio          float64        `json:"thin_ratio,omitempty"`           // Thin provisioning ratio, ProvisionedSize / ActualSize.
+	DiskSizeByTier     map[string]int `json:"disk_size_by_tier,omitempty"`    // The virtual size of all disks attached to vm, in bytes, by storage tier of the disk storage domain.
+	VmDisksCount       int            `json:"vm_disks_count,omitempty"`       // The count of attached virtual disks
+	SharedDisksCount   int            `json:"shared_disks_count,omitempty"`   // The count of attached shareable disks. A shared disk is counted in sizes of the first VM it is attached to.
+	HasIllegalImages   bool           `json:"has_illegal_images,omitempty"`   // The virtual machine has snapshot images in illegal state.
+	SnapshotsCount     int            `json:"snapshots_count,omitempty"`      // The count of snapshots, the active snapshot is not counted.
+	SnapshotsSize      int            `json:"snapshots_size,omitempty"`       // The actual size of disk images of snapshots, in bytes.
+	OldestSnapshotDays int            `json:"oldest_snapshot_days,omitempty"` // Days passed since the oldest snapshot was created.
+	Nics               []nicStats     `json:"nics,omitempty"`                 // Network interfaces of the virtual machine.
+	IPv4               []string       `json:"ipv4,omitempty"`                 // IPv4 addresses reported by the guest agent.
+	IPv6               []string       `json:"ipv6,omitempty"`                 // IPv6 addresses reported by the guest agent.
+}
+
+// Inventory of one or several engines.
+type inventory struct {
+	VMs            []vmStats            `json:"vms"`
+	Disks          []diskStats          `json:"disks"`
+	StorageDomains []storageDomainStats `json:"storage_domains"`
+	Hosts          []hostStats          `json:"hosts"`
+	Clusters       []clusterStats       `json:"clusters"`
+	DataCenters    []dataCenterStats    `json:"data_centers"`
+	Templates      []templateStats      `json:"templates"`
+	Snapshots      []snapshotStats      `json:"snapshots"`
+	DuplicateMACs  []duplicateMAC       `json:"duplicate_macs"`
+	Networks       []networkStats       `json:"networks"`
+	VnicProfiles   []vnicProfileStats   `json:"vnic_profiles"`
+}
+
+type storageDomainStats struct {
+	Engine      string              `json:"engine,omitempty"`       // Name of the oVirt engine the storage domain is fetched from.
+	ID          string              `json:"id,omitempty"`           // A unique identifier.
+	Name        string              `json:"name,omitempty"`         // A human-readable name in plain text.
+	Type        StorageDomainType   `json:"type,omitempty"`         // The kind of data managed by the storage domain: data, iso, export, ...
+	StorageType StorageType         `json:"storage_type,omitempty"` // Type of the storage: nfs, iscsi, fcp, glusterfs, ...
+	Status      StorageDomainStatus `json:"status,omitempty"`       // Status of the storage domain, empty for domains attached to a data center.
+	Tier        string              `json:"tier,omitempty"`         // Storage tier of the domain, see tierClassifier.
+
+	Available       int     `json:"available"`                  // Free space, in bytes.
+	Used            int     `json:"used"`                       // Used space, in bytes.
+	Committed       int     `json:"committed"`                  // Space committed to disks, the sum of their virtual sizes, in bytes.
+	Total           int     `json:"total"`                      // Capacity, Available + Used, in bytes.
+	UsedPercent     float64 `json:"used_percent"`               // Used / Total, in percent.
+	OvercommitRatio float64 `json:"overcommit_ratio,omitempty"` // Committed / Total. Above 1 the disks can't grow to their virtual size all together.
+
+	WarningLowSpace int  `json:"warning_low_space,omitempty"` // WarningLowSpaceIndicator of the domain, in percent of free space.
+	CriticalSpace   int  `json:"critical_space,omitempty"`    // CriticalSpaceActionBlocker of the domain, in GiB of free space.
+	LowSpace        bool `json:"low_space,omitempty"`         // Free space is below WarningLowSpace.
+	CriticalLow     bool `json:"critical_low,omitempty"`      // Free space is below CriticalSpace, the engine blocks actions on the domain.
+
+	VMs   []string `json:"vms,omitempty"`   // Names of the virtual machines with disks on the domain.
+	Disks []string `json:"disks,omitempty"` // Aliases of the disks on the domain.
+}
+
+type hostStats struct {
+	Engine       string     `json:"engine,omitempty"`        // Name of the oVirt engine the host is fetched from.
+	DataCenter   string     `json:"data_center,omitempty"`   // Name of the data center of the host cluster.
+	Cluster      string     `json:"cluster,omitempty"`       // Name of the cluster the host belongs to.
+	ID           string     `json:"id,omitempty"`            // A unique identifier.
+	Name         string     `json:"name,omitempty"`          // A human-readable name in plain text.
+	Address      string     `json:"address,omitempty"`       // The host address (FQDN/IP).
+	Status       HostStatus `json:"status,omitempty"`        // The host status.
+	Type         HostType   `json:"type,omitempty"`          // Full OS installation (rhel) or oVirt Node (ovirt_node).
+	Manufacturer string     `json:"manufacturer,omitempty"`  // Manufacturer of the host hardware.
+	Model        string     `json:"model,omitempty"`         // Product name of the host hardware.
+	SerialNumber string     `json:"serial_number,omitempty"` // Serial number of the host chassis.
+	UUID         string     `json:"uuid,omitempty"`          // Hardware UUID of the host.
+
+	CpuModel   string  `json:"cpu_model,omitempty"`   // Name of the host CPU model.
+	CpuSpeed   float64 `json:"cpu_speed,omitempty"`   // CPU speed, in MHz.
+	CpuSockets int     `json:"cpu_sockets,omitempty"` // Count of CPU sockets.
+	CpuCores   int     `json:"cpu_cores,omitempty"`   // Count of physical cores, sockets × cores.
+	CpuThreads int     `json:"cpu_threads,omitempty"` // Count of logical CPUs, sockets × cores × threads.
+	Memory     int     `json:"memory,omitempty"`      // The amount of physical memory, in bytes.
+
+	OS             string      `json:"os,omitempty"`              // Operating system of the host with version.
+	VdsmVersion    string      `json:"vdsm_version,omitempty"`    // Version of VDSM.
+	LibvirtVersion string      `json:"libvirt_version,omitempty"` // Version of libvirt.
+	SeLinux        SeLinuxMode `json:"selinux,omitempty"`         // SELinux mode: enforcing, permissive or disabled.
+	KsmEnabled     bool        `json:"ksm_enabled,omitempty"`     // Kernel SamePage Merging is enabled.
+	Spm            SpmStatus   `json:"spm,omitempty"`             // Storage pool manager status: spm, contending or none.
+
+	UpdateAvailable bool `json:"update_available,omitempty"` // There is an oVirt-related update on the host.
+	VmsActive       int  `json:"vms_active,omitempty"`       // The number of virtual machines active on the host.
+	VmsMigrating    int  `json:"vms_migrating,omitempty"`    // The number of virtual machines migrating to or from the host.
+	VmsTotal        int  `json:"vms_total,omitempty"`        // The number of virtual machines present on the host.
+}
+
+// Resources of hosts and VMs rolled up to a cluster or a data center.
+type rollup struct {
+	HostsCount     int `json:"hosts_count"`      // The count of hosts.
+	HostCpuThreads int `json:"host_cpu_threads"` // Logical CPUs of all hosts.
+	HostMemory     int `json:"host_memory"`      // Physical memory of all hosts, in bytes.
+	VmsCount       int `json:"vms_count"`        // The count of virtual machines.
+	VmsRunning     int `json:"vms_running"`      // The count of running virtual machines.
+	Cpu            int `json:"cpu"`              // Virtual CPUs of all virtual machines.
+	Memory         int `json:"memory"`           // Memory of all virtual machines, in bytes.
+	RunningCpu     int `json:"running_cpu"`      // Virtual CPUs of running virtual machines.
+	RunningMemory  int `json:"running_memory"`   // Memory of running virtual machines, in bytes.
+}
+
+type clusterStats struct {
+	Engine     string `json:"engine,omitempty"`      // Name of the oVirt engine the cluster is fetched from.
+	ID         string `json:"id,omitempty"`          // A unique identifier.
+	Name       string `json:"name,omitempty"`        // A human-readable name in plain text.
+	DataCenter string `json:"data_center,omitempty"` // Name of the data center the cluster belongs to.
+	CpuType    string `json:"cpu_type,omitempty"`    // The CPU type of the cluster.
+	Version    string `json:"version,omitempty"`     // The compatibility version of the cluster.
+	rollup     `yaml:",inline"`
+}
+
+type dataCenterStats struct {
+	Engine        string           `json:"engine,omitempty"`  // Name of the oVirt engine the data center is fetched from.
+	ID            string           `json:"id,omitempty"`      // A unique identifier.
+	Name          string           `json:"name,omitempty"`    // A human-readable name in plain text.
+	Status        DataCenterStatus `json:"status,omitempty"`  // The status of the data center.
+	Version       string           `json:"version,omitempty"` // The compatibility version of the data center.
+	ClustersCount int              `json:"clusters_count"`    // The count of clusters.
+	rollup        `yaml:",inline"`
+}
+
+type templateStats struct {
+	Engine        string         `json:"engine,omitempty"`         // Name of the oVirt engine the template is fetched from.
+	ID            string         `json:"id,omitempty"`             // A unique identifier.
+	Name          string         `json:"name,omitempty"`           // A human-readable name in plain text.
+	Description   string         `json:"description,omitempty"`    // A human-readable description in plain text.
+	Status        TemplateStatus `json:"status,omitempty"`         // The status of the template: ok, locked or illegal.
+	Cluster       string         `json:"cluster,omitempty"`        // Name of the cluster the template belongs to.
+	CreationTime  Timestamp      `json:"creation_time,omitempty"`  // The template creation date.
+	BaseTemplate  string         `json:"base_template,omitempty"`  // Name of the base template of the version chain.
+	VersionName   string         `json:"version_name,omitempty"`   // The name of this version.
+	VersionNumber int            `json:"version_number,omitempty"` // The index of this version in the version chain.
+	LatestVersion int            `json:"latest_version,omitempty"` // The index of the latest version in the version chain.
+	Outdated      bool           `json:"outdated,omitempty"`       // There is a newer version in the version chain.
+
+	ProvisionedSize int `json:"provisioned_size"` // The virtual size of template disks, in bytes.
+	ActualSize      int `json:"actual_size"`      // The actual size of template disks, in bytes.
+	DisksCount      int `json:"disks_count"`      // The count of template disks.
+	VmsCount        int `json:"vms_count"`        // The count of virtual machines created from this version.
+}
+
+type snapshotStats struct {
+	Engine        string         `json:"engine,omitempty"`         // Name of the oVirt engine the snapshot is fetched from.
+	VmID          string         `json:"vm_id,omitempty"`          // ID of the virtual machine the snapshot belongs to.
+	VmName        string         `json:"vm_name,omitempty"`        // Name of the virtual machine the snapshot belongs to.
+	ID            string         `json:"id,omitempty"`             // A unique identifier.
+	Description   string         `json:"description,omitempty"`    // A human-readable description in plain text.
+	Date          Timestamp      `json:"date,omitempty"`           // The date when the snapshot has been created.
+	AgeDays       int            `json:"age_days"`                 // Days passed since the snapshot creation.
+	Type          SnapshotType   `json:"type,omitempty"`           // Type of the snapshot: regular, preview or stateless.
+	Status        SnapshotStatus `json:"status,omitempty"`         // Status of the snapshot: ok, locked or in_preview.
+	PersistMemory bool           `json:"persist_memory,omitempty"` // The content of the memory of the virtual machine is included in the snapshot.
+	Size          int            `json:"size"`                     // The actual size of disk images of the snapshot, in bytes.
+	DisksCount    int            `json:"disks_count"`              // The count of disk images of the snapshot.
+	Old           bool           `json:"old,omitempty"`            // The snapshot is older than the configured number of days.
+}
+
+type nicStats struct {
+	Name         string       `json:"name,omitempty"`          // A human-readable name in plain text.
+	MAC          string       `json:"mac,omitempty"`           // The MAC address of the interface.
+	Interface    NicInterface `json:"interface,omitempty"`     // The type of driver used for the NIC.
+	VnicProfile  string       `json:"vnic_profile,omitempty"`  // Name of the vNIC profile of the NIC.
+	Network      string       `json:"network,omitempty"`       // Name of the network of the vNIC profile.
+	Vlan         int          `json:"vlan,omitempty"`          // VLAN ID of the network, 0 for an untagged network.
+	Linked       bool         `json:"linked"`                  // The NIC is linked to the network.
+	Plugged      bool         `json:"plugged"`                 // The NIC is plugged in to the virtual machine.
+	IPv4         []string     `json:"ipv4,omitempty"`          // IPv4 addresses reported by the guest agent for the NIC.
+	IPv6         []string     `json:"ipv6,omitempty"`          // IPv6 addresses reported by the guest agent for the NIC.
+	DuplicateMAC bool         `json:"duplicate_mac,omitempty"` // The MAC address is used by another NIC in the inventory.
+}
+
+type networkStats struct {
+	Engine      string         `json:"engine,omitempty"`      // Name of the oVirt engine the network is fetched from.
+	ID          string         `json:"id,omitempty"`          // A unique identifier.
+	Name        string         `json:"name,omitempty"`        // A human-readable name in plain text.
+	Description string         `json:"description,omitempty"` // A human-readable description in plain text.
+	DataCenter  string         `json:"data_center,omitempty"` // Name of the data center the network belongs to.
+	Vlan        int            `json:"vlan,omitempty"`        // VLAN ID, 0 for an untagged network.
+	Mtu         int            `json:"mtu,omitempty"`         // The maximum transmission unit, 0 is the default of the engine, 1500.
+	Usages      []NetworkUsage `json:"usages,omitempty"`      // Usages of the network: vm, management, display, migration, gluster, default_route.
+	Required    bool           `json:"required,omitempty"`    // Hosts are not operational without the network.
+	Qos         string         `json:"qos,omitempty"`         // Name of the host network QoS of the network.
+	Profiles    []string       `json:"profiles,omitempty"`    // Names of vNIC profiles of the network.
+	NicsCount   int            `json:"nics_count"`            // The count of VM NICs on the network.
+	VMs         []string       `json:"vms,omitempty"`         // Names of the virtual machines with NICs on the network.
+	Hosts       []string       `json:"hosts,omitempty"`       // Names of the hosts the network is attached to.
+	Unused      bool           `json:"unused,omitempty"`      // No VM NIC is on the network and no host has it attached.
+}
+
+type vnicProfileStats struct {
+	Engine        string   `json:"engine,omitempty"`         // Name of the oVirt engine the vNIC profile is fetched from.
+	ID            string   `json:"id,omitempty"`             // A unique identifier.
+	Name          string   `json:"name,omitempty"`           // A human-readable name in plain text.
+	Network       string   `json:"network,omitempty"`        // Name of the network the vNIC profile applies to.
+	Vlan          int      `json:"vlan,omitempty"`           // VLAN ID of the network, 0 for an untagged network.
+	Qos           string   `json:"qos,omitempty"`            // Name of the network QoS of the vNIC profile.
+	PortMirroring bool     `json:"port_mirroring,omitempty"` // Port mirroring is enabled.
+	NicsCount     int      `json:"nics_count"`               // The count of VM NICs using the profile.
+	VMs           []string `json:"vms,omitempty"`            // Names of the virtual machines with NICs using the profile.
+	Unused        bool     `json:"unused,omitempty"`         // No VM NIC uses the profile.
+}
+
+type diskStats struct {
+	Engine          string          `json:"engine,omitempty"`          // Name of the oVirt engine the disk is fetched from.
+	ID              string          `json:"id,omitempty"`              // A unique identifier.
+	Alias           string          `json:"alias,omitempty"`           // Alias of the disk, its ID if the alias is empty.
+	Description     string          `json:"description,omitempty"`     // A human-readable description in plain text.
+	VMs             []string        `json:"vms,omitempty"`             // Names of the virtual machines the disk is attached to.
+	StorageDomains  []string        `json:"storage_domains,omitempty"` // Names of the storage domains the disk resides on.
+	Tier            string          `json:"tier,omitempty"`            // Storage tier of the disk, see tierClassifier.
+	Format          DiskFormat      `json:"format,omitempty"`          // The underlying storage format: cow or raw.
+	Sparse          bool            `json:"sparse"`                    // The physical storage of the disk is not preallocated.
+	Shareable       bool            `json:"shareable"`                 // The disk can be attached to several virtual machines.
+	Status          DiskStatus      `json:"status,omitempty"`          // The status of the disk: ok, locked or illegal.
+	ContentType     DiskContentType `json:"content_type,omitempty"`    // The content of the disk: data, iso, memory_dump_volume, ...
+	ProvisionedSize int             `json:"provisioned_size"`          // The virtual size of the disk, in bytes.
+	ActualSize      int             `json:"actual_size"`               // The actual size of the disk, in bytes.
+	TotalSize       int             `json:"total_size"`                // The size of the disk including its snapshots, in bytes.
 }
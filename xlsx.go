@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheets of the XLSX workbook: the sheet name and the report written to the sheet.
+var xlsxSheets = []struct{ name, report string }{
+	{"VMs", "vms"},
+	{"Disks", "disks"},
+	{"Hosts", "hosts"},
+	{"Storage domains", "storage_domains"},
+}
+
+// Name of the sheet with totals per cluster and storage tier.
+const xlsxSummarySheet = "Summary"
+
+const (
+	xlsxSizeFormat = `#,##0.00 "GiB"`
+	xlsxDateFormat = "yyyy-mm-dd hh:mm"
+)
+
+// Styles of the workbook cells.
+type xlsxStyles struct {
+	header int
+	size   int
+	date   int
+}
+
+// func writeXLSX - write the inventory as XLSX workbook
+//
+// The workbook holds the summary sheet and a sheet per report of xlsxSheets. Each report sheet
+// has a frozen header row and an autofilter, sizes are written in GiB, dates as Excel dates.
+func writeXLSX(w io.Writer, inv *inventory) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+	if err := f.SetSheetName("Sheet1", xlsxSummarySheet); err != nil {
+		return err
+	}
+	if err := writeXLSXSummary(f, styles, inv); err != nil {
+		return err
+	}
+	for _, s := range xlsxSheets {
+		if _, err := f.NewSheet(s.name); err != nil {
+			return err
+		}
+		if err := writeXLSXReport(f, styles, s.name, inv, s.report); err != nil {
+			return fmt.Errorf("sheet %s: %w", s.name, err)
+		}
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
+
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	var s xlsxStyles
+	var err error
+	if s.header, err = f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+	}); err != nil {
+		return s, err
+	}
+	sizeFormat, dateFormat := xlsxSizeFormat, xlsxDateFormat
+	if s.size, err = f.NewStyle(&excelize.Style{CustomNumFmt: &sizeFormat}); err != nil {
+		return s, err
+	}
+	s.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	return s, err
+}
+
+// func writeXLSXReport - write rows of the report to the sheet with a header row
+func writeXLSXReport(f *excelize.File, styles xlsxStyles, sheet string, inv *inventory, report string) error {
+	columns, err := selectColumns(report, nil)
+	if err != nil {
+		return err
+	}
+	rows := reportRows(inv, report)
+	// Column styles apply to cells written later, the header row is styled on its own.
+	header := make([]string, len(columns))
+	for j, c := range columns {
+		header[j] = columnTitle(c.name)
+		col, _ := excelize.ColumnNumberToName(j + 1)
+		switch {
+		case sizeColumns[c.name]:
+			err = f.SetColStyle(sheet, col, styles.size)
+		case rows.Type().Elem().FieldByIndex(c.index).Type == timestampType:
+			err = f.SetColStyle(sheet, col, styles.date)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := writeXLSXHeader(f, styles, sheet, 1, header); err != nil {
+		return err
+	}
+	for i := 0; i < rows.Len(); i++ {
+		row := rows.Index(i)
+		values := make([]any, len(columns))
+		for j, c := range columns {
+			if values[j], err = xlsxValue(row.FieldByIndex(c.index), sizeColumns[c.name]); err != nil {
+				return err
+			}
+		}
+		if err := f.SetSheetRow(sheet, cellName(1, i+2), &values); err != nil {
+			return err
+		}
+	}
+	last := cellName(len(columns), rows.Len()+1)
+	if err := f.AutoFilter(sheet, "A1:"+last, nil); err != nil {
+		return err
+	}
+	return f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+}
+
+// func writeXLSXHeader - write the header row and size columns to fit the header
+func writeXLSXHeader(f *excelize.File, styles xlsxStyles, sheet string, row int, header []string) error {
+	if err := f.SetSheetRow(sheet, cellName(1, row), &header); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, cellName(1, row), cellName(len(header), row), styles.header); err != nil {
+		return err
+	}
+	for i, h := range header {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		width, _ := f.GetColWidth(sheet, col)
+		if w := float64(len(h) + 4); w > width {
+			if err := f.SetColWidth(sheet, col, col, w); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var timestampType = reflect.TypeOf(Timestamp{})
+
+// func xlsxValue - value of a cell, numbers stay numbers, sizes are in GiB, other values as in CSV
+func xlsxValue(v reflect.Value, size bool) (any, error) {
+	if t, ok := v.Interface().(Timestamp); ok {
+		if t.IsZero() {
+			return nil, nil
+		}
+		return t.Time, nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if size {
+			return gib(int(v.Int())), nil
+		}
+		return v.Int(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+	return csvValue(v)
+}
+
+// func gib - bytes in GiB
+func gib(bytes int) float64 {
+	return float64(bytes) / (1 << 30)
+}
+
+func cellName(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+// Totals of a cluster on the summary sheet.
+type clusterTotals struct {
+	engine, dataCenter, name string
+	rollup
+	provisionedSize, actualSize int
+}
+
+// Totals of a storage tier on the summary sheet.
+type tierTotals struct {
+	name                              string
+	domainsCount                      int
+	total, used, available, committed int
+	vmsProvisioned                    int
+}
+
+// func writeXLSXSummary - write tables of totals per cluster and per storage tier
+func writeXLSXSummary(f *excelize.File, styles xlsxStyles, inv *inventory) error {
+	sheet := xlsxSummarySheet
+	row := 1
+	header := []string{"Engine", "Data center", "Cluster", "Hosts", "Host CPU threads", "Host memory",
+		"VMs", "VMs running", "CPU", "Memory", "Provisioned size", "Actual size"}
+	if err := writeXLSXHeader(f, styles, sheet, row, header); err != nil {
+		return err
+	}
+	for _, c := range summaryClusters(inv) {
+		row++
+		values := []any{c.engine, c.dataCenter, c.name, c.HostsCount, c.HostCpuThreads, gib(c.HostMemory),
+			c.VmsCount, c.VmsRunning, c.Cpu, gib(c.Memory), gib(c.provisionedSize), gib(c.actualSize)}
+		if err := f.SetSheetRow(sheet, cellName(1, row), &values); err != nil {
+			return err
+		}
+		for _, col := range []int{6, 10, 11, 12} {
+			if err := f.SetCellStyle(sheet, cellName(col, row), cellName(col, row), styles.size); err != nil {
+				return err
+			}
+		}
+	}
+
+	row += 2
+	header = []string{"Tier", "Storage domains", "Total", "Used", "Available", "Committed", "VMs provisioned size"}
+	if err := writeXLSXHeader(f, styles, sheet, row, header); err != nil {
+		return err
+	}
+	for _, t := range summaryTiers(inv) {
+		row++
+		values := []any{t.name, t.domainsCount, gib(t.total), gib(t.used), gib(t.available), gib(t.committed), gib(t.vmsProvisioned)}
+		if err := f.SetSheetRow(sheet, cellName(1, row), &values); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, cellName(3, row), cellName(7, row), styles.size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// func summaryClusters - totals per cluster, clusters in the order of the inventory
+func summaryClusters(inv *inventory) []*clusterTotals {
+	totals := make([]*clusterTotals, 0, len(inv.Clusters))
+	byName := make(map[[2]string]*clusterTotals, len(inv.Clusters))
+	for _, c := range inv.Clusters {
+		t := &clusterTotals{engine: c.Engine, dataCenter: c.DataCenter, name: c.Name, rollup: c.rollup}
+		totals = append(totals, t)
+		byName[[2]string{c.Engine, c.Name}] = t
+	}
+	for _, vm := range inv.VMs {
+		if t, ok := byName[[2]string{vm.Engine, vm.Cluster}]; ok {
+			t.provisionedSize += vm.ProvisionedSize
+			t.actualSize += vm.ActualSize
+		}
+	}
+	return totals
+}
+
+// func summaryTiers - totals per storage tier, sorted by the tier name
+func summaryTiers(inv *inventory) []*tierTotals {
+	byName := make(map[string]*tierTotals)
+	tier := func(name string) *tierTotals {
+		t, ok := byName[name]
+		if !ok {
+			t = &tierTotals{name: name}
+			byName[name] = t
+		}
+		return t
+	}
+	for _, d := range inv.StorageDomains {
+		t := tier(d.Tier)
+		t.domainsCount++
+		t.total += d.Total
+		t.used += d.Used
+		t.available += d.Available
+		t.committed += d.Committed
+	}
+	for _, vm := range inv.VMs {
+		for name, size := range vm.DiskSizeByTier {
+			tier(name).vmsProvisioned += size
+		}
+	}
+	totals := make([]*tierTotals, 0, len(byName))
+	for _, t := range byName {
+		totals = append(totals, t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].name < totals[j].name })
+	return totals
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	inv := testInventory()
+	inv.VMs[0].Cluster = "prod"
+	inv.VMs[0].ProvisionedSize = 30 << 30
+	inv.Clusters[0].Engine = "engine1"
+	inv.Clusters[0].HostMemory = 512 << 30
+	inv.StorageDomains = []storageDomainStats{
+		{Name: "FC-SSD-01", Tier: "ssd", Total: 100 << 30, Used: 40 << 30, Available: 60 << 30},
+		{Name: "FC-SSD-02", Tier: "ssd", Total: 100 << 30, Used: 10 << 30, Available: 90 << 30},
+	}
+
+	var buf bytes.Buffer
+	if err := writeInventory(&buf, outputConfig{Format: formatXLSX}, inv); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if got, want := f.GetSheetList(), []string{"Summary", "VMs", "Disks", "Hosts", "Storage domains"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sheets = %v, want %v", got, want)
+	}
+	cells := []struct {
+		sheet, cell, want string
+	}{
+		{"VMs", "A1", "Engine"},
+		{"VMs", "A2", "engine1"},
+		{"Summary", "C2", "prod"},
+		{"Summary", "F2", "512.00 GiB"},
+		{"Summary", "K2", "30.00 GiB"},
+		{"Summary", "A5", "hdd"},
+		{"Summary", "B5", "0"},
+		{"Summary", "A6", "ssd"},
+		{"Summary", "B6", "2"},
+		{"Summary", "C6", "200.00 GiB"},
+		{"Summary", "D6", "50.00 GiB"},
+	}
+	for _, c := range cells {
+		got, err := f.GetCellValue(c.sheet, c.cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("%s!%s = %q, want %q", c.sheet, c.cell, got, c.want)
+		}
+	}
+	panes, err := f.GetPanes("VMs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("panes of VMs = %+v, want the frozen header row", panes)
+	}
+}